@@ -0,0 +1,112 @@
+package main
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/lib/hostname"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/storage/postgres"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage: admin <command> [flags]
+
+commands:
+  retention prune [-dry-run] [-retention d]  delete raw clicks past their retention period
+  retention set -alias a [-domain h] -keep d|default
+                                             override the raw click retention of a link
+`
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "retention" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.MustLoad()
+
+	storage, err := postgres.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("failed to init storage: %v", err)
+	}
+
+	switch os.Args[2] {
+	case "prune":
+		prune(storage, cfg, os.Args[3:])
+	case "set":
+		setRetention(storage, os.Args[3:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func prune(storage *postgres.Storage, cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("retention prune", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only report what would be deleted")
+	retention := fs.Duration("retention", cfg.Analytics.RawRetention, "global retention period, overrides the config")
+	_ = fs.Parse(args)
+
+	now := time.Now()
+
+	stats, err := storage.PruneReport(context.Background(), now, *retention)
+	if err != nil {
+		log.Fatalf("failed to build report: %v", err)
+	}
+
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ALIAS\tCLICKS\tOLDEST\tNEWEST")
+	for _, st := range stats {
+		total += st.Clicks
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", st.Alias, st.Clicks, st.Oldest.Format(time.RFC3339), st.Newest.Format(time.RFC3339))
+	}
+	_ = w.Flush()
+
+	fmt.Printf("%d raw clicks of %d links are expired\n", total, len(stats))
+
+	if *dryRun || total == 0 {
+		return
+	}
+
+	deleted, err := storage.PruneClicks(context.Background(), now, *retention)
+	if err != nil {
+		log.Fatalf("failed to prune clicks: %v", err)
+	}
+
+	fmt.Printf("%d raw clicks deleted\n", deleted)
+}
+
+func setRetention(store *postgres.Storage, args []string) {
+	fs := flag.NewFlagSet("retention set", flag.ExitOnError)
+	alias := fs.String("alias", "", "link alias")
+	domain := fs.String("domain", "", "custom domain of the link, empty for the default domain")
+	keep := fs.String("keep", "", `retention period, "0" to keep forever or "default" for the global period`)
+	_ = fs.Parse(args)
+
+	if *alias == "" || *keep == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var period *time.Duration
+	if *keep != "default" {
+		d, err := time.ParseDuration(*keep)
+		if err != nil || d < 0 {
+			log.Fatalf("invalid -keep %q", *keep)
+		}
+		period = &d
+	}
+
+	ctx := storage.WithDomain(context.Background(), hostname.Normalize(*domain))
+	if err := store.SetLinkRetention(ctx, *alias, period); err != nil {
+		log.Fatalf("failed to set retention: %v", err)
+	}
+
+	fmt.Printf("retention of %s set to %s\n", *alias, *keep)
+}
@@ -1,20 +1,51 @@
 package main
 
 import (
+	"analiticsURLShortener/internal/clicks"
 	"analiticsURLShortener/internal/config"
 	"analiticsURLShortener/internal/http-server/handlers/analytics"
+	"analiticsURLShortener/internal/http-server/handlers/analytics/events"
+	"analiticsURLShortener/internal/http-server/handlers/analytics/heatmap"
+	"analiticsURLShortener/internal/http-server/handlers/analytics/live"
+	"analiticsURLShortener/internal/http-server/handlers/analytics/stats"
+	"analiticsURLShortener/internal/http-server/handlers/apikey"
+	"analiticsURLShortener/internal/http-server/handlers/badge"
+	"analiticsURLShortener/internal/http-server/handlers/dashboard"
+	"analiticsURLShortener/internal/http-server/handlers/domain"
+	"analiticsURLShortener/internal/http-server/handlers/health"
+	"analiticsURLShortener/internal/http-server/handlers/preview"
+	"analiticsURLShortener/internal/http-server/handlers/qr"
 	"analiticsURLShortener/internal/http-server/handlers/redirect"
+	"analiticsURLShortener/internal/http-server/handlers/static"
 	"analiticsURLShortener/internal/http-server/handlers/url/save"
+	mwDomain "analiticsURLShortener/internal/http-server/middleware/domain"
 	mwLogger "analiticsURLShortener/internal/http-server/middleware/logger"
+	mwMetrics "analiticsURLShortener/internal/http-server/middleware/metrics"
+	"analiticsURLShortener/internal/http-server/middleware/realip"
+	mwTracing "analiticsURLShortener/internal/http-server/middleware/tracing"
+	"analiticsURLShortener/internal/jobs"
+	"analiticsURLShortener/internal/lib/geoip"
 	"analiticsURLShortener/internal/lib/logger/handlers/slogpretty"
 	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/lib/shortlink"
+	"analiticsURLShortener/internal/metrics"
 	"analiticsURLShortener/internal/storage/postgres"
+	"analiticsURLShortener/internal/tracing"
+	assets "analiticsURLShortener/static"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 const (
@@ -24,6 +55,12 @@ const (
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run starts the service and returns the exit code once it has stopped, so
+// that deferred cleanup such as flushing traces runs on every path.
+func run() int {
 	cfg := config.MustLoad()
 
 	log := setupLogger(cfg.Env)
@@ -31,27 +68,194 @@ func main() {
 	log.Info("Starting url-shortener", slog.String("env", cfg.Env))
 	log.Debug("debug messages are enabled")
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, "url-shortener")
+	if err != nil {
+		log.Error("failed to init tracing", sl.Err(err))
+		return 1
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error("failed to flush traces", sl.Err(err))
+		}
+	}()
+
 	storage, err := postgres.InitDB(cfg)
 	if err != nil {
 		log.Error("failed to init storage", sl.Err(err))
-		os.Exit(1)
+		return 1
+	}
+
+	m := metrics.New()
+	storage.SetObserver(m)
+	m.RegisterDB(storage.DB(), "postgres")
+
+	var geo clicks.GeoLocator
+	if cfg.Analytics.GeoIPPath != "" {
+		geoReader, err := geoip.Open(cfg.Analytics.GeoIPPath)
+		if err != nil {
+			log.Error("failed to open geoip database", sl.Err(err))
+			return 1
+		}
+		defer geoReader.Close()
+
+		geo = geoReader
+	}
+
+	hub := clicks.NewHub(cfg.Analytics.Live)
+	tracker := clicks.NewTracker(log, storage, geo, hub, cfg.Analytics)
+	m.RegisterClickQueue(tracker)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	var jobsWG sync.WaitGroup
+	runJob := func(job jobs.Job) {
+		jobsWG.Add(1)
+		go func() {
+			defer jobsWG.Done()
+			jobs.Run(jobsCtx, log, job)
+		}()
+	}
+
+	runJob(jobs.Job{
+		Name:     "rollup",
+		Interval: cfg.Analytics.RollupInterval,
+		Fn:       storage.RollupClicks,
+	})
+
+	runJob(jobs.Job{
+		Name:     "retention",
+		Interval: cfg.Analytics.RetentionInterval,
+		Fn: func(ctx context.Context, now time.Time) error {
+			deleted, err := storage.PruneClicks(ctx, now, cfg.Analytics.RawRetention)
+			if deleted > 0 {
+				log.Info("expired clicks deleted", slog.Int64("rows", deleted))
+			}
+			return err
+		},
+	})
+
+	runJob(jobs.Job{
+		Name:     "partitions",
+		Interval: cfg.Analytics.PartitionInterval,
+		Fn: func(ctx context.Context, now time.Time) error {
+			report, err := storage.MaintainPartitions(ctx, now, cfg.Analytics.RawRetention, cfg.Analytics.DetachPartitions)
+			if len(report.Created) > 0 || len(report.Removed) > 0 {
+				log.Info("click partitions updated",
+					slog.Any("created", report.Created),
+					slog.Any("removed", report.Removed),
+				)
+			}
+			return err
+		},
+	})
+
+	trustedProxies, err := realip.ParseCIDRs(cfg.HTTPServer.TrustedProxies)
+	if err != nil {
+		log.Error("failed to parse trusted proxies", sl.Err(err))
+		return 1
 	}
 
-	_ = storage
+	links, err := shortlink.New(cfg.PublicBaseURL, cfg.LinkPrefix)
+	if err != nil {
+		log.Error("failed to parse short link settings", sl.Err(err))
+		return 1
+	}
 
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(realip.New(trustedProxies))
+	router.Use(mwTracing.New())
 	router.Use(middleware.Logger)
 	router.Use(mwLogger.New(log))
+	router.Use(mwMetrics.New(m))
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 
-	router.Handle("/*", http.FileServer(http.Dir("./static")))
+	healthState := &health.State{}
+	router.Get("/healthz", health.NewLiveness())
+	router.Get("/readyz", health.NewReadiness(log, healthState, cfg.Health.CheckTimeout,
+		health.Check{Name: "database", Fn: storage.Ping},
+		health.Check{Name: "schema", Fn: storage.CheckSchema},
+		health.Check{Name: "click_queue", Fn: func(context.Context) error {
+			if s := tracker.Saturation(); s > cfg.Health.MaxQueueSaturation {
+				return fmt.Errorf("click queue is %.0f%% full", s*100)
+			}
+			return nil
+		}},
+	))
+
+	var staticFS fs.FS = assets.FS
+	if cfg.Static.Dir != "" {
+		staticFS = os.DirFS(cfg.Static.Dir)
+	}
+	files := static.New(staticFS, static.Options{MaxAge: cfg.Static.MaxAge, Reload: cfg.Static.Dir != ""})
+	router.Handle("/*", files)
+
+	previewHandler := preview.New(log, storage, links)
+	var redirectHandler http.Handler = redirect.New(log, tracker, m, previewHandler)
+	if links.Root() {
+		// static files take precedence over root-level aliases
+		redirectHandler = static.Fallback(staticFS, files, redirectHandler)
+	}
+
+	globalStats := stats.NewCache(storage, cfg.Analytics.StatsCacheTTL)
 
-	router.Post("/shorten", save.New(log, storage))
-	router.Get("/s/{short_url}", redirect.New(log, storage))
-	router.Get("/analytics/{short_url}", analytics.New(log, storage))
+	// aliases are resolved among the links of the domain in the Host header
+	router.Group(func(r chi.Router) {
+		r.Use(mwDomain.New(log, storage, cfg.DomainCacheTTL))
+
+		r.Post("/shorten", save.New(log, storage, links))
+		r.Method(http.MethodGet, links.Prefix()+"{short_url}", redirectHandler)
+		r.Get(links.Prefix()+"{short_url}/info", previewHandler)
+		r.Get("/analytics", analytics.NewCompare(log, storage))
+		r.Get("/analytics/{short_url}", analytics.New(log, storage))
+		r.Get("/analytics/{short_url}/events", events.New(log, storage, cfg.Analytics.Export))
+		r.Get("/analytics/{short_url}/heatmap", heatmap.New(log, storage))
+		r.Get("/analytics/{short_url}/live", live.New(log, storage, hub, cfg.Analytics.Live))
+		r.Get("/stats", stats.New(log, globalStats))
+		r.Get("/dashboard", dashboard.NewOverview(log, globalStats))
+		r.Get("/dashboard/{short_url}", dashboard.New(log, storage))
+		r.Get("/badge/{short_url}", badge.New(log, storage, cfg.Badges))
+		r.Get("/sparkline/{short_url}", badge.NewSparkline(log, storage, cfg.Badges))
+		r.Get("/qr/{short_url}", qr.New(log, storage, links))
+	})
+
+	if links.Root() {
+		if err := reservePaths(links, router, staticFS, cfg.ReservedPaths); err != nil {
+			log.Error("failed to reserve paths", sl.Err(err))
+			return 1
+		}
+	}
+
+	var adminSrv *http.Server
+	if cfg.Admin.Address != "" {
+		adminRouter := chi.NewRouter()
+		adminRouter.Use(middleware.RequestID)
+		adminRouter.Handle("/metrics", m.Handler())
+		adminRouter.Post("/domains", domain.NewSave(log, storage))
+		adminRouter.Get("/domains", domain.NewList(log, storage))
+		adminRouter.Post("/api-keys", apikey.NewSave(log, storage))
+		adminRouter.Get("/api-keys", apikey.NewList(log, storage))
+		adminRouter.Delete("/api-keys/{prefix}", apikey.NewDelete(log, storage))
+
+		adminSrv = &http.Server{
+			Addr:        cfg.Admin.Address,
+			Handler:     adminRouter,
+			ReadTimeout: cfg.HTTPServer.Timeout,
+			IdleTimeout: cfg.HTTPServer.IdleTimeout,
+		}
+
+		go func() {
+			log.Info("starting admin server", slog.String("address", cfg.Admin.Address))
+			if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("admin server stopped", sl.Err(err))
+			}
+		}()
+	}
 
 	log.Info("starting server", slog.String("address", cfg.HTTPServer.Address))
 
@@ -62,12 +266,84 @@ func main() {
 		WriteTimeout: cfg.HTTPServer.Timeout,
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
+	// Shutdown doesn't cancel the contexts of open requests, so live streams
+	// are ended explicitly
+	srv.RegisterOnShutdown(hub.Close)
 
-	if err = srv.ListenAndServe(); err != nil {
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		log.Info("shutting down")
+	case err := <-serverErr:
 		log.Error("failed to start server", sl.Err(err))
+		exitCode = 1
+	}
+	stop()
+
+	// fail readiness first and give load balancers time to notice
+	healthState.SetShuttingDown()
+	time.Sleep(cfg.HTTPServer.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	// in-flight requests may still queue clicks, so the tracker is closed after them
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to drain http server", sl.Err(err))
+	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to stop admin server", sl.Err(err))
+		}
+	}
+
+	// the drain may have used up shutdownCtx, so the flush gets its own deadline
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), cfg.Analytics.FlushTimeout)
+	defer cancelFlush()
+
+	if err := tracker.Close(flushCtx); err != nil {
+		log.Error("failed to flush clicks", sl.Err(err))
+	}
+
+	stopJobs()
+	jobsWG.Wait()
+
+	if err := storage.Close(); err != nil {
+		log.Error("failed to close storage", sl.Err(err))
+	}
+
+	log.Info("server stopped")
+
+	return exitCode
+}
+
+// reservePaths keeps root-level aliases from taking the first path segment of
+// a route, a static file or a configured path.
+func reservePaths(links *shortlink.Builder, routes chi.Routes, staticFS fs.FS, extra []string) error {
+	err := chi.Walk(routes, func(_, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if !strings.ContainsAny(segment, "{*") {
+			links.Reserve(segment)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	names, err := static.TopLevelNames(staticFS)
+	if err != nil {
+		return err
 	}
+	links.Reserve(names...)
+	links.Reserve(extra...)
 
-	log.Error("server stopped", sl.Err(srv.Shutdown(context.Background())))
+	return nil
 }
 
 func setupLogger(env string) *slog.Logger {
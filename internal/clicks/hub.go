@@ -0,0 +1,205 @@
+package clicks
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/storage"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Policy decides what happens when a subscriber does not keep up and its
+// buffer is full.
+type Policy string
+
+const (
+	// DropOldest discards the oldest buffered event to make room.
+	DropOldest Policy = "drop_oldest"
+	// DropNewest discards the incoming event.
+	DropNewest Policy = "drop_newest"
+	// Disconnect closes the subscription.
+	Disconnect Policy = "disconnect"
+)
+
+var (
+	ErrTooManySubscribers = errors.New("too many subscribers")
+	ErrInvalidPolicy      = errors.New("invalid backpressure policy")
+	// ErrSlowSubscriber is why a subscriber that didn't keep up was disconnected.
+	ErrSlowSubscriber = errors.New("slow subscriber")
+	// ErrHubClosed is returned by Subscribe after Close, and is why the
+	// subscriptions open at that time were ended.
+	ErrHubClosed = errors.New("hub is closed")
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case DropOldest, DropNewest, Disconnect:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidPolicy, s)
+	}
+}
+
+// Subscription receives the click events of one alias.
+type Subscription struct {
+	key     linkKey
+	policy  Policy
+	events  chan storage.ClickEvent
+	done    chan struct{}
+	once    sync.Once
+	err     error
+	dropped atomic.Int64
+}
+
+// Events returns the channel of published events.
+func (s *Subscription) Events() <-chan storage.ClickEvent {
+	return s.events
+}
+
+// Done is closed when the hub ends the subscription: the subscriber was too
+// slow or the hub is closed.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the hub ended the subscription once Done is closed:
+// ErrSlowSubscriber or ErrHubClosed. It is nil after Unsubscribe.
+func (s *Subscription) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Dropped returns how many events were discarded for this subscriber.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription) close(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// linkKey identifies a link, since aliases are only unique per domain.
+type linkKey struct {
+	domain string
+	alias  string
+}
+
+// Hub fans click events out to live subscribers of each alias.
+type Hub struct {
+	bufferSize     int
+	maxSubscribers int
+
+	mu     sync.Mutex
+	subs   map[linkKey]map[*Subscription]struct{}
+	count  int
+	closed bool
+}
+
+func NewHub(cfg config.Live) *Hub {
+	return &Hub{
+		bufferSize:     cfg.BufferSize,
+		maxSubscribers: cfg.MaxSubscribers,
+		subs:           make(map[linkKey]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe returns a subscription to the clicks of alias on domain, "" for
+// the default domain.
+func (h *Hub) Subscribe(domain, alias string, policy Policy) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+	if h.maxSubscribers > 0 && h.count >= h.maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	key := linkKey{domain: domain, alias: alias}
+	sub := &Subscription{
+		key:    key,
+		policy: policy,
+		events: make(chan storage.ClickEvent, h.bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	if h.subs[key] == nil {
+		h.subs[key] = make(map[*Subscription]struct{})
+	}
+	h.subs[key][sub] = struct{}{}
+	h.count++
+
+	return sub, nil
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub.key][sub]; !ok {
+		return
+	}
+
+	delete(h.subs[sub.key], sub)
+	if len(h.subs[sub.key]) == 0 {
+		delete(h.subs, sub.key)
+	}
+	h.count--
+	sub.close(nil)
+}
+
+// Close ends every open subscription with ErrHubClosed and rejects new ones,
+// so that live streams don't hold up server shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			sub.close(ErrHubClosed)
+		}
+	}
+}
+
+// Publish delivers e to every subscriber of its link without blocking.
+func (h *Hub) Publish(e storage.ClickEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[linkKey{domain: e.Domain, alias: e.Alias}] {
+		h.deliver(sub, e)
+	}
+}
+
+func (h *Hub) deliver(sub *Subscription, e storage.ClickEvent) {
+	select {
+	case sub.events <- e:
+		return
+	default:
+	}
+
+	switch sub.policy {
+	case DropNewest:
+		sub.dropped.Add(1)
+	case Disconnect:
+		sub.close(ErrSlowSubscriber)
+	default:
+		// Publish holds the lock, so nobody else can fill the freed slot
+		select {
+		case <-sub.events:
+			sub.dropped.Add(1)
+		default:
+		}
+		select {
+		case sub.events <- e:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
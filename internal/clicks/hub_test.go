@@ -0,0 +1,115 @@
+package clicks
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/storage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(alias, ua string) storage.ClickEvent {
+	return storage.ClickEvent{Alias: alias, Click: storage.Click{UserAgent: ua}}
+}
+
+func drain(sub *Subscription) []string {
+	var got []string
+	for {
+		select {
+		case e := <-sub.Events():
+			got = append(got, e.UserAgent)
+		default:
+			return got
+		}
+	}
+}
+
+func TestHub_Policies(t *testing.T) {
+	tests := []struct {
+		policy       Policy
+		expected     []string
+		dropped      int64
+		disconnected bool
+	}{
+		{policy: DropOldest, expected: []string{"2", "3"}, dropped: 1},
+		{policy: DropNewest, expected: []string{"1", "2"}, dropped: 1},
+		{policy: Disconnect, expected: []string{"1", "2"}, disconnected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			hub := NewHub(config.Live{BufferSize: 2})
+
+			sub, err := hub.Subscribe("", "a", tt.policy)
+			require.NoError(t, err)
+			other, err := hub.Subscribe("", "b", tt.policy)
+			require.NoError(t, err)
+
+			hub.Publish(event("a", "1"))
+			hub.Publish(event("a", "2"))
+			hub.Publish(event("a", "3"))
+
+			assert.Equal(t, tt.expected, drain(sub))
+			assert.Equal(t, tt.dropped, sub.Dropped())
+			assert.Empty(t, drain(other))
+
+			select {
+			case <-sub.Done():
+				assert.True(t, tt.disconnected)
+			default:
+				assert.False(t, tt.disconnected)
+			}
+		})
+	}
+}
+
+func TestHub_MaxSubscribers(t *testing.T) {
+	hub := NewHub(config.Live{BufferSize: 1, MaxSubscribers: 1})
+
+	sub, err := hub.Subscribe("", "a", DropOldest)
+	require.NoError(t, err)
+
+	_, err = hub.Subscribe("", "b", DropOldest)
+	assert.ErrorIs(t, err, ErrTooManySubscribers)
+
+	hub.Unsubscribe(sub)
+	hub.Unsubscribe(sub)
+
+	_, err = hub.Subscribe("", "b", DropOldest)
+	assert.NoError(t, err)
+}
+
+func TestHub_Domains(t *testing.T) {
+	hub := NewHub(config.Live{BufferSize: 1})
+
+	sub, err := hub.Subscribe("brand.example", "promo", DropOldest)
+	require.NoError(t, err)
+
+	hub.Publish(storage.ClickEvent{Alias: "promo"})
+	assert.Empty(t, sub.Events())
+
+	hub.Publish(storage.ClickEvent{Domain: "brand.example", Alias: "promo"})
+	assert.Len(t, sub.Events(), 1)
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(config.Live{BufferSize: 1})
+
+	sub, err := hub.Subscribe("", "a", DropOldest)
+	require.NoError(t, err)
+
+	hub.Close()
+
+	select {
+	case <-sub.Done():
+	default:
+		t.Fatal("subscription is still open")
+	}
+	assert.ErrorIs(t, sub.Err(), ErrHubClosed)
+
+	_, err = hub.Subscribe("", "a", DropOldest)
+	assert.ErrorIs(t, err, ErrHubClosed)
+
+	hub.Unsubscribe(sub)
+}
@@ -0,0 +1,239 @@
+package clicks
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/lib/geoip"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrQueueFull = errors.New("click queue is full")
+	ErrClosed    = errors.New("tracker is closed")
+)
+
+type Store interface {
+	GetURL(ctx context.Context, alias string) (string, error)
+	SaveClick(ctx context.Context, e storage.ClickEvent) error
+	VisitorSalt(ctx context.Context, day time.Time) ([]byte, error)
+}
+
+type GeoLocator interface {
+	Lookup(ip string) (geoip.Location, error)
+}
+
+type Publisher interface {
+	Publish(e storage.ClickEvent)
+}
+
+var tracer = otel.Tracer("analiticsURLShortener/internal/clicks")
+
+// queuedClick carries the span of the request that produced the click, so
+// that its background ingestion can be linked to it. The domain of the
+// request travels in the event.
+type queuedClick struct {
+	event storage.ClickEvent
+	span  trace.SpanContext
+}
+
+// Tracker wraps a Store and ingests clicks in the background: they are
+// queued, enriched, saved and then published to live subscribers.
+type Tracker struct {
+	Store
+	log       *slog.Logger
+	geo       GeoLocator
+	publisher Publisher
+	keepIP    bool
+	now       func() time.Time
+
+	queue   chan queuedClick
+	workers sync.WaitGroup
+	closeMu sync.RWMutex
+	closed  bool
+
+	failures atomic.Int64
+
+	saltMu  sync.Mutex
+	saltDay time.Time
+	salt    []byte
+}
+
+// NewTracker returns a Tracker for store and starts its workers. geo and
+// publisher may be nil, in which case clicks are saved without location data
+// and not published.
+func NewTracker(log *slog.Logger, store Store, geo GeoLocator, publisher Publisher, cfg config.Analytics) *Tracker {
+	t := &Tracker{
+		Store:     store,
+		log:       log.With(slog.String("component", "clicks/tracker")),
+		geo:       geo,
+		publisher: publisher,
+		keepIP:    cfg.KeepIP,
+		now:       time.Now,
+		queue:     make(chan queuedClick, max(cfg.QueueSize, 1)),
+	}
+
+	for i := 0; i < max(cfg.Workers, 1); i++ {
+		t.workers.Add(1)
+		go t.work()
+	}
+
+	return t
+}
+
+// SaveAnalytics queues the click without waiting for it to be stored.
+func (t *Tracker) SaveAnalytics(ctx context.Context, alias string, click storage.Click) error {
+	t.closeMu.RLock()
+	defer t.closeMu.RUnlock()
+
+	if t.closed {
+		return ErrClosed
+	}
+
+	select {
+	case t.queue <- queuedClick{
+		event: storage.ClickEvent{Domain: storage.DomainFromContext(ctx), Alias: alias, Time: t.now(), Click: click},
+		span:  trace.SpanContextFromContext(ctx),
+	}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Saturation returns the share of the click queue in use, from 0 to 1.
+func (t *Tracker) Saturation() float64 {
+	return float64(len(t.queue)) / float64(cap(t.queue))
+}
+
+// Failures returns how many queued clicks couldn't be saved.
+func (t *Tracker) Failures() int64 {
+	return t.failures.Load()
+}
+
+// Close stops accepting clicks and waits until the queued ones are saved or
+// ctx is done.
+func (t *Tracker) Close(ctx context.Context) error {
+	t.closeMu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.queue)
+	}
+	t.closeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracker) work() {
+	defer t.workers.Done()
+
+	for c := range t.queue {
+		ctx, span := tracer.Start(storage.WithDomain(context.Background(), c.event.Domain), "clicks.ingest",
+			trace.WithLinks(trace.Link{SpanContext: c.span}),
+			trace.WithAttributes(attribute.String("alias", c.event.Alias)),
+		)
+		t.ingest(ctx, c.event)
+		span.End()
+	}
+}
+
+func (t *Tracker) ingest(ctx context.Context, e storage.ClickEvent) {
+	t.enrich(ctx, &e)
+
+	if err := t.Store.SaveClick(ctx, e); err != nil {
+		t.failures.Add(1)
+		t.log.Error("failed to save click", slog.String("alias", e.Alias), sl.Err(err))
+		return
+	}
+
+	if t.publisher != nil {
+		t.publisher.Publish(e)
+	}
+}
+
+func (t *Tracker) enrich(ctx context.Context, e *storage.ClickEvent) {
+	if t.geo != nil && e.IP != "" {
+		loc, err := t.geo.Lookup(e.IP)
+		if err != nil {
+			t.log.Debug("geoip lookup failed", sl.Err(err))
+		} else {
+			e.Country = loc.Country
+			e.Region = loc.Region
+			e.City = loc.City
+		}
+	}
+
+	// a kept IP would link visitor IDs across salt rotations, so the two
+	// are never stored together
+	if t.keepIP {
+		return
+	}
+
+	if e.IP != "" {
+		visitorID, err := t.visitorID(ctx, e.Time, e.IP, e.UserAgent)
+		if err != nil {
+			t.log.Error("failed to compute visitor id", sl.Err(err))
+		}
+		e.VisitorID = visitorID
+	}
+	e.IP = ""
+}
+
+// visitorID hashes ip and userAgent with the salt of the UTC day of at.
+func (t *Tracker) visitorID(ctx context.Context, at time.Time, ip, userAgent string) (string, error) {
+	salt, err := t.saltFor(ctx, at)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+func (t *Tracker) saltFor(ctx context.Context, at time.Time) ([]byte, error) {
+	day := at.UTC().Truncate(24 * time.Hour)
+
+	t.saltMu.Lock()
+	defer t.saltMu.Unlock()
+
+	if t.salt != nil && t.saltDay.Equal(day) {
+		return t.salt, nil
+	}
+
+	salt, err := t.Store.VisitorSalt(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+
+	t.salt = salt
+	t.saltDay = day
+
+	return salt, nil
+}
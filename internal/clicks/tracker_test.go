@@ -0,0 +1,223 @@
+package clicks
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/lib/geoip"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	saved   []storage.Click
+	times   []time.Time
+	domains []string
+	salts   map[time.Time][]byte
+	release chan struct{}
+	err     error
+}
+
+func (s *fakeStore) VisitorSalt(_ context.Context, day time.Time) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.salts == nil {
+		s.salts = make(map[time.Time][]byte)
+	}
+	if _, ok := s.salts[day]; !ok {
+		s.salts[day] = []byte(day.String())
+	}
+	return s.salts[day], nil
+}
+
+func (s *fakeStore) GetURL(_ context.Context, alias string) (string, error) {
+	return "https://example.com/" + alias, nil
+}
+
+func (s *fakeStore) SaveClick(ctx context.Context, e storage.ClickEvent) error {
+	if s.release != nil {
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.saved = append(s.saved, e.Click)
+	s.times = append(s.times, e.Time)
+	s.domains = append(s.domains, storage.DomainFromContext(ctx))
+	return nil
+}
+
+type fakePublisher struct {
+	mu     sync.Mutex
+	events []storage.ClickEvent
+}
+
+func (p *fakePublisher) Publish(e storage.ClickEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, e)
+}
+
+type fakeGeo map[string]geoip.Location
+
+func (g fakeGeo) Lookup(ip string) (geoip.Location, error) {
+	loc, ok := g[ip]
+	if !ok {
+		return geoip.Location{}, errors.New("not found")
+	}
+	return loc, nil
+}
+
+func TestTracker_SaveAnalytics(t *testing.T) {
+	geo := fakeGeo{"203.0.113.7": {Country: "DE", Region: "Bavaria", City: "Munich"}}
+
+	tests := []struct {
+		name   string
+		geo    GeoLocator
+		keepIP bool
+		click  storage.Click
+		want   storage.Click
+	}{
+		{
+			name:  "Enriched and IP discarded",
+			geo:   geo,
+			click: storage.Click{UserAgent: "ua", IP: "203.0.113.7"},
+			want:  storage.Click{UserAgent: "ua", Country: "DE", Region: "Bavaria", City: "Munich"},
+		},
+		{
+			name:   "Enriched and IP kept",
+			geo:    geo,
+			keepIP: true,
+			click:  storage.Click{IP: "203.0.113.7"},
+			want:   storage.Click{IP: "203.0.113.7", Country: "DE", Region: "Bavaria", City: "Munich"},
+		},
+		{
+			name:  "Lookup failure",
+			geo:   geo,
+			click: storage.Click{IP: "198.51.100.1"},
+			want:  storage.Click{},
+		},
+		{
+			name:  "No database",
+			click: storage.Click{IP: "203.0.113.7"},
+			want:  storage.Click{},
+		},
+		{
+			name:  "No IP",
+			click: storage.Click{UserAgent: "ua"},
+			want:  storage.Click{UserAgent: "ua"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			publisher := &fakePublisher{}
+			tracker := NewTracker(slog.Default(), store, tt.geo, publisher, config.Analytics{KeepIP: tt.keepIP})
+
+			assert.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", tt.click))
+			require.NoError(t, tracker.Close(context.Background()))
+			require.Len(t, store.saved, 1)
+
+			got := store.saved[0]
+			if tt.click.IP != "" && !tt.keepIP {
+				assert.Len(t, got.VisitorID, 32)
+			}
+			if tt.keepIP {
+				assert.Empty(t, got.VisitorID, "kept IPs are never stored with a visitor id")
+			}
+			require.Len(t, publisher.events, 1)
+			assert.Equal(t, "alias", publisher.events[0].Alias)
+			assert.Equal(t, got, publisher.events[0].Click)
+
+			got.VisitorID = ""
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTracker_VisitorIDRotatesDaily(t *testing.T) {
+	store := &fakeStore{}
+	tracker := NewTracker(slog.Default(), store, nil, nil, config.Analytics{QueueSize: 10})
+
+	day := time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return day }
+
+	click := storage.Click{UserAgent: "ua", IP: "203.0.113.7"}
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", click))
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", click))
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", storage.Click{UserAgent: "other", IP: "203.0.113.7"}))
+
+	tracker.now = func() time.Time { return day.Add(24 * time.Hour) }
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", click))
+	require.NoError(t, tracker.Close(context.Background()))
+
+	require.Len(t, store.saved, 4)
+	assert.Equal(t, []time.Time{day, day, day, day.Add(24 * time.Hour)}, store.times)
+	assert.Equal(t, store.saved[0].VisitorID, store.saved[1].VisitorID)
+	assert.NotEqual(t, store.saved[0].VisitorID, store.saved[2].VisitorID)
+	assert.NotEqual(t, store.saved[0].VisitorID, store.saved[3].VisitorID)
+	assert.Empty(t, store.saved[3].IP)
+}
+
+func TestTracker_QueueFull(t *testing.T) {
+	store := &fakeStore{release: make(chan struct{})}
+	tracker := NewTracker(slog.Default(), store, nil, nil, config.Analytics{QueueSize: 1, Workers: 1})
+
+	// the worker takes the first click and blocks, the second fills the queue
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", storage.Click{}))
+	require.Eventually(t, func() bool { return tracker.Saturation() == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", storage.Click{}))
+	assert.Equal(t, 1.0, tracker.Saturation())
+
+	assert.ErrorIs(t, tracker.SaveAnalytics(context.Background(), "alias", storage.Click{}), ErrQueueFull)
+
+	close(store.release)
+	require.NoError(t, tracker.Close(context.Background()))
+	assert.Len(t, store.saved, 2)
+	assert.Zero(t, tracker.Failures())
+
+	assert.ErrorIs(t, tracker.SaveAnalytics(context.Background(), "alias", storage.Click{}), ErrClosed)
+}
+
+func TestTracker_Failures(t *testing.T) {
+	store := &fakeStore{err: errors.New("db is down")}
+	publisher := &fakePublisher{}
+	tracker := NewTracker(slog.Default(), store, nil, publisher, config.Analytics{QueueSize: 10})
+
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", storage.Click{}))
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "alias", storage.Click{}))
+	require.NoError(t, tracker.Close(context.Background()))
+
+	assert.Equal(t, int64(2), tracker.Failures())
+	assert.Empty(t, publisher.events)
+}
+
+func TestTracker_Domain(t *testing.T) {
+	store := &fakeStore{}
+	publisher := &fakePublisher{}
+	tracker := NewTracker(slog.Default(), store, nil, publisher, config.Analytics{QueueSize: 10})
+
+	ctx := storage.WithDomain(context.Background(), "brand.example")
+	require.NoError(t, tracker.SaveAnalytics(ctx, "promo", storage.Click{}))
+	require.NoError(t, tracker.SaveAnalytics(context.Background(), "promo", storage.Click{}))
+	require.NoError(t, tracker.Close(context.Background()))
+
+	assert.ElementsMatch(t, []string{"brand.example", ""}, store.domains)
+	require.Len(t, publisher.events, 2)
+	assert.ElementsMatch(t, []string{"brand.example", ""},
+		[]string{publisher.events[0].Domain, publisher.events[1].Domain})
+}
@@ -12,6 +12,22 @@ type Config struct {
 	Env        string     `yaml:"env" env-default:"local"`
 	Database   Database   `yaml:"database"`
 	HTTPServer HTTPServer `yaml:"http_server"`
+	Analytics  Analytics  `yaml:"analytics"`
+	Admin      Admin      `yaml:"admin"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Health     Health     `yaml:"health"`
+	Static     Static     `yaml:"static"`
+	Badges     Badges     `yaml:"badges"`
+	// PublicBaseURL is the scheme and host short links are built with, e.g.
+	// "https://sho.rt"; empty uses the host of each request.
+	PublicBaseURL string `yaml:"public_base_url"`
+	// LinkPrefix is the path short links are served under; "/" serves them at the root.
+	LinkPrefix string `yaml:"link_prefix" env-default:"/s/"`
+	// ReservedPaths can't be taken by aliases when LinkPrefix is "/". Routes and
+	// static files are always reserved.
+	ReservedPaths []string `yaml:"reserved_paths"`
+	// DomainCacheTTL is how long the lookup of a request host among custom domains is cached.
+	DomainCacheTTL time.Duration `yaml:"domain_cache_ttl" env-default:"1m"`
 }
 
 type Database struct {
@@ -27,6 +43,99 @@ type HTTPServer struct {
 	Address     string        `yaml:"address" env-default:"localhost:8082"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	// ShutdownDelay is how long /readyz fails before the server stops accepting
+	// connections, so that load balancers stop routing to it first.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env-default:"0s"`
+	// ShutdownTimeout bounds draining in-flight requests on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+	// TrustedProxies lists CIDRs of proxies allowed to set Forwarded/X-Forwarded-For.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type Admin struct {
+	// Address of the listener serving /metrics and /domains; empty disables it.
+	Address string `yaml:"address" env-default:"localhost:9090"`
+}
+
+type Tracing struct {
+	// Exporter is "otlp", "stdout", "file" or empty to only propagate trace context.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector address.
+	Endpoint string `yaml:"endpoint" env-default:"localhost:4318"`
+	Insecure bool   `yaml:"insecure" env-default:"true"`
+	// File receives spans as JSON lines with the file exporter.
+	File string `yaml:"file" env-default:"traces.json"`
+	// SampleRatio is the share of new traces that are recorded; incoming sampled traces are always kept.
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+type Static struct {
+	// Dir serves the UI from disk and reloads it on every request, for
+	// development; empty serves the files embedded in the binary.
+	Dir string `yaml:"dir"`
+	// MaxAge is how long browsers may cache assets; HTML pages are always revalidated.
+	MaxAge time.Duration `yaml:"max_age" env-default:"1h"`
+}
+
+type Badges struct {
+	// MaxAge is how long browsers and proxies may cache badges and sparklines.
+	MaxAge time.Duration `yaml:"max_age" env-default:"5m"`
+	// SparklineDays is the default window of a sparkline; the days parameter overrides it.
+	SparklineDays int `yaml:"sparkline_days" env-default:"30"`
+}
+
+type Health struct {
+	// CheckTimeout bounds all readiness checks of a single /readyz request.
+	CheckTimeout time.Duration `yaml:"check_timeout" env-default:"2s"`
+	// MaxQueueSaturation is the click queue share above which the service reports not ready.
+	MaxQueueSaturation float64 `yaml:"max_queue_saturation" env-default:"0.9"`
+}
+
+type Analytics struct {
+	// GeoIPPath points to a MaxMind-format city database; empty disables geo enrichment.
+	GeoIPPath string `yaml:"geoip_db_path"`
+	// KeepIP stores the client IP next to the click instead of discarding it after lookup.
+	// Visitor IDs aren't computed then, so unique visitors aren't counted.
+	KeepIP bool `yaml:"keep_ip" env-default:"false"`
+	// RollupInterval is how often raw clicks are aggregated into rollup tables; 0 disables it.
+	RollupInterval time.Duration `yaml:"rollup_interval" env-default:"1m"`
+	// RawRetention is how long raw clicks are kept after being rolled up; 0 keeps them forever.
+	// Links can override it with the admin command.
+	RawRetention time.Duration `yaml:"raw_retention" env-default:"0"`
+	// RetentionInterval is how often expired raw clicks are deleted; 0 disables pruning.
+	RetentionInterval time.Duration `yaml:"retention_interval" env-default:"1h"`
+	// PartitionInterval is how often monthly click partitions are created and expired; 0 disables it.
+	PartitionInterval time.Duration `yaml:"partition_interval" env-default:"6h"`
+	// DetachPartitions detaches expired partitions instead of dropping them, e.g. to archive them.
+	DetachPartitions bool `yaml:"detach_partitions" env-default:"false"`
+	// QueueSize bounds the clicks waiting to be saved; clicks beyond it are dropped.
+	QueueSize int  `yaml:"queue_size" env-default:"10000"`
+	Workers   int  `yaml:"workers" env-default:"2"`
+	Live      Live `yaml:"live"`
+	// FlushTimeout bounds saving queued clicks on shutdown, after in-flight
+	// requests are drained.
+	FlushTimeout time.Duration `yaml:"flush_timeout" env-default:"10s"`
+	// StatsCacheTTL is how long global stats of /stats and /dashboard are cached; 0 disables the cache.
+	StatsCacheTTL time.Duration `yaml:"stats_cache_ttl" env-default:"1m"`
+	Export        Export        `yaml:"export"`
+}
+
+type Export struct {
+	// WriteTimeout bounds sending each batch of exported events; the write
+	// deadline moves forward after every batch. 0 removes the deadline.
+	WriteTimeout time.Duration `yaml:"write_timeout" env-default:"30s"`
+	// MaxDuration bounds a whole export; longer exports are cut off. 0 means no limit.
+	MaxDuration time.Duration `yaml:"max_duration" env-default:"10m"`
+}
+
+type Live struct {
+	// MaxSubscribers limits concurrent live streams across all links; 0 means no limit.
+	MaxSubscribers int `yaml:"max_subscribers" env-default:"100"`
+	// BufferSize is how many events a slow subscriber may fall behind.
+	BufferSize int `yaml:"buffer_size" env-default:"256"`
+	// Policy is the default backpressure policy: drop_oldest, drop_newest or disconnect.
+	Policy            string        `yaml:"policy" env-default:"drop_oldest"`
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" env-default:"15s"`
 }
 
 func MustLoad() *Config {
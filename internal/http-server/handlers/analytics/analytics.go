@@ -3,27 +3,55 @@ package analytics
 import (
 	"analiticsURLShortener/internal/lib/api/response"
 	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/lib/timeseries"
 	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 )
 
+const maxBuckets = 2000
+
 type AnalyticsResponse struct {
 	response.Response
-	TotalClicks int64            `json:"total_clicks"`
-	UserAgents  map[string]int64 `json:"user_agents"`
-	Daily       map[string]int64 `json:"daily_clicks"`
-	Monthly     map[string]int64 `json:"monthly_clicks"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	Granularity    string           `json:"granularity"`
+	Timezone       string           `json:"timezone"`
+	TotalClicks    int64            `json:"total_clicks"`
+	UniqueVisitors int64            `json:"unique_visitors"`
+	Series         []Point          `json:"series"`
+	UserAgents     map[string]int64 `json:"user_agents"`
+	Referrers      []Breakdown      `json:"top_referrers"`
+	Sources        []Breakdown      `json:"top_sources"`
+	Countries      []Breakdown      `json:"top_countries"`
+	Regions        []Breakdown      `json:"top_regions"`
+	Cities         []Breakdown      `json:"top_cities"`
+}
+
+type Point struct {
+	Time    time.Time `json:"time"`
+	Clicks  int64     `json:"clicks"`
+	Uniques int64     `json:"uniques"`
+}
+
+type Breakdown struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=URLAnalyticsGetter
 type URLAnalyticsGetter interface {
-	GetAnalytics(alias string) (storage.AnalyticsData, error)
+	GetAnalytics(ctx context.Context, alias string, q storage.AnalyticsQuery) (storage.AnalyticsData, error)
 }
 
 func New(log *slog.Logger, analyticsGetter URLAnalyticsGetter) http.HandlerFunc {
@@ -33,6 +61,7 @@ func New(log *slog.Logger, analyticsGetter URLAnalyticsGetter) http.HandlerFunc
 		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
 		alias := chi.URLParam(r, "short_url")
@@ -43,7 +72,15 @@ func New(log *slog.Logger, analyticsGetter URLAnalyticsGetter) http.HandlerFunc
 			return
 		}
 
-		analyticsData, err := analyticsGetter.GetAnalytics(alias)
+		q, err := ParseQuery(r, time.Now())
+		if err != nil {
+			log.Info("invalid analytics query", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		analyticsData, err := analyticsGetter.GetAnalytics(r.Context(), alias, q)
 		if errors.Is(err, storage.ErrURLNotFound) {
 			log.Info("url not found", "alias", alias)
 			render.Status(r, http.StatusNotFound)
@@ -57,16 +94,187 @@ func New(log *slog.Logger, analyticsGetter URLAnalyticsGetter) http.HandlerFunc
 			return
 		}
 
-		responseOK(w, r, analyticsData)
+		responseOK(w, r, q, analyticsData)
+	}
+}
+
+// ParseQuery reads the from, to, granularity and tz query parameters.
+// from and to accept RFC 3339 timestamps or dates; a date in to is
+// inclusive. Without from, a default window ending at to is used.
+func ParseQuery(r *http.Request, now time.Time) (storage.AnalyticsQuery, error) {
+	params := r.URL.Query()
+
+	loc, err := parseLocation(params)
+	if err != nil {
+		return storage.AnalyticsQuery{}, err
+	}
+
+	g := timeseries.Day
+	if s := params.Get("granularity"); s != "" {
+		var err error
+		if g, err = timeseries.ParseGranularity(s); err != nil {
+			return storage.AnalyticsQuery{}, fmt.Errorf("invalid granularity %q", s)
+		}
+	}
+
+	to, err := parseTo(params, loc, now)
+	if err != nil {
+		return storage.AnalyticsQuery{}, err
 	}
+
+	from, err := parseFrom(params, loc, defaultFrom(to, g, loc))
+	if err != nil {
+		return storage.AnalyticsQuery{}, err
+	}
+
+	if !from.Before(to) {
+		return storage.AnalyticsQuery{}, errors.New("from must be before to")
+	}
+
+	if timeseries.Count(from, to, g, loc, maxBuckets) > maxBuckets {
+		return storage.AnalyticsQuery{}, fmt.Errorf("range has more than %d buckets, use a coarser granularity", maxBuckets)
+	}
+
+	return storage.AnalyticsQuery{
+		From:        from,
+		To:          to,
+		Granularity: g,
+		Location:    loc,
+	}, nil
+}
+
+// ParseRange reads the from, to and tz query parameters of raw click exports
+// the same way as ParseQuery. There is no default window and no bucket limit:
+// without from the range starts at the first click.
+func ParseRange(r *http.Request, now time.Time) (time.Time, time.Time, error) {
+	params := r.URL.Query()
+
+	loc, err := parseLocation(params)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	to, err := parseTo(params, loc, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	from, err := parseFrom(params, loc, time.Time{})
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+
+	return from, to, nil
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, data storage.AnalyticsData) {
+func parseLocation(params url.Values) (*time.Location, error) {
+	tz := params.Get("tz")
+	if tz == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil || loc.String() == "Local" {
+		return nil, fmt.Errorf("invalid tz %q", tz)
+	}
+
+	return loc, nil
+}
+
+func parseTo(params url.Values, loc *time.Location, now time.Time) (time.Time, error) {
+	s := params.Get("to")
+	if s == "" {
+		return now, nil
+	}
+
+	t, dateOnly, err := parseTime(s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid to %q", s)
+	}
+	if dateOnly {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+func parseFrom(params url.Values, loc *time.Location, def time.Time) (time.Time, error) {
+	s := params.Get("from")
+	if s == "" {
+		return def, nil
+	}
+
+	t, _, err := parseTime(s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid from %q", s)
+	}
+
+	return t, nil
+}
+
+func parseTime(s string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, s, loc)
+
+	return t, true, err
+}
+
+func defaultFrom(to time.Time, g timeseries.Granularity, loc *time.Location) time.Time {
+	var from time.Time
+	switch g {
+	case timeseries.Hour:
+		from = to.Add(-47 * time.Hour)
+	case timeseries.Week:
+		from = to.AddDate(0, 0, -7*11)
+	case timeseries.Month:
+		from = to.AddDate(0, -11, 0)
+	default:
+		from = to.AddDate(0, 0, -29)
+	}
+
+	return timeseries.Truncate(from, g, loc)
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, q storage.AnalyticsQuery, data storage.AnalyticsData) {
 	render.JSON(w, r, AnalyticsResponse{
-		Response:    response.OK(),
-		TotalClicks: data.TotalClicks,
-		UserAgents:  data.UserAgents,
-		Daily:       data.Daily,
-		Monthly:     data.Monthly,
+		Response:       response.OK(),
+		From:           q.From.In(q.Location),
+		To:             q.To.In(q.Location),
+		Granularity:    string(q.Granularity),
+		Timezone:       q.Location.String(),
+		TotalClicks:    data.TotalClicks,
+		UniqueVisitors: data.UniqueVisitors,
+		Series:         series(data.Series, q.Location),
+		UserAgents:     data.UserAgents,
+		Referrers:      breakdown(data.Referrers),
+		Sources:        breakdown(data.Sources),
+		Countries:      breakdown(data.Countries),
+		Regions:        breakdown(data.Regions),
+		Cities:         breakdown(data.Cities),
 	})
 }
+
+func series(points []storage.Point, loc *time.Location) []Point {
+	res := make([]Point, 0, len(points))
+	for _, p := range points {
+		res = append(res, Point{Time: p.Time.In(loc), Clicks: p.Clicks, Uniques: p.Uniques})
+	}
+
+	return res
+}
+
+func breakdown(counts []storage.Count) []Breakdown {
+	res := make([]Breakdown, 0, len(counts))
+	for _, c := range counts {
+		res = append(res, Breakdown{Value: c.Value, Clicks: c.Clicks})
+	}
+
+	return res
+}
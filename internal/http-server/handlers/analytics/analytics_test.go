@@ -2,6 +2,7 @@ package analytics
 
 import (
 	"analiticsURLShortener/internal/http-server/handlers/analytics/mocks"
+	"analiticsURLShortener/internal/lib/timeseries"
 	"analiticsURLShortener/internal/storage"
 	"context"
 	"encoding/json"
@@ -11,14 +12,18 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type testCase struct {
 	name          string
 	alias         string
+	query         string
 	mockError     error
 	mockAnalytics storage.AnalyticsData
 	expectedCode  int
@@ -26,27 +31,41 @@ type testCase struct {
 }
 
 func TestNew(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
 	tests := []testCase{
 		{
 			name:  "Success",
 			alias: "test-alias",
+			query: "?from=2023-10-26&to=2023-10-27&tz=Europe/Berlin",
 			mockAnalytics: storage.AnalyticsData{
-				TotalClicks: 10,
+				TotalClicks:    10,
+				UniqueVisitors: 6,
+				Series: []storage.Point{
+					{Time: time.Date(2023, 10, 26, 0, 0, 0, 0, berlin), Clicks: 10, Uniques: 6},
+					{Time: time.Date(2023, 10, 27, 0, 0, 0, 0, berlin)},
+				},
 				UserAgents: map[string]int64{
 					"Mozilla/5.0": 7,
 					"Googlebot":   3,
 				},
-				Daily: map[string]int64{
-					"2023-10-26": 5,
-					"2023-10-27": 5,
+				Referrers: []storage.Count{
+					{Value: "google.com", Clicks: 6},
+					{Value: "direct", Clicks: 4},
+				},
+				Sources: []storage.Count{
+					{Value: "newsletter", Clicks: 2},
+				},
+				Countries: []storage.Count{
+					{Value: "DE", Clicks: 8},
 				},
-				Monthly: map[string]int64{
-					"2023-10": 10,
+				Regions: []storage.Count{
+					{Value: "Bavaria", Clicks: 8},
 				},
 			},
 			mockError:    nil,
 			expectedCode: http.StatusOK,
-			expectedBody: `{"status":"OK","total_clicks":10,"user_agents":{"Googlebot":3,"Mozilla/5.0":7},"daily_clicks":{"2023-10-26":5,"2023-10-27":5},"monthly_clicks":{"2023-10":10}}`,
+			expectedBody: `{"status":"OK","from":"2023-10-26T00:00:00+02:00","to":"2023-10-28T00:00:00+02:00","granularity":"day","timezone":"Europe/Berlin","total_clicks":10,"unique_visitors":6,"series":[{"time":"2023-10-26T00:00:00+02:00","clicks":10,"uniques":6},{"time":"2023-10-27T00:00:00+02:00","clicks":0,"uniques":0}],"user_agents":{"Googlebot":3,"Mozilla/5.0":7},"top_referrers":[{"value":"google.com","clicks":6},{"value":"direct","clicks":4}],"top_sources":[{"value":"newsletter","clicks":2}],"top_countries":[{"value":"DE","clicks":8}],"top_regions":[{"value":"Bavaria","clicks":8}],"top_cities":[]}`,
 		},
 		{
 			name:         "URL Not Found",
@@ -68,21 +87,56 @@ func TestNew(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: `{"status":"Error","error":"invalid request"}`,
 		},
+		{
+			name:         "Invalid Granularity",
+			alias:        "test-alias",
+			query:        "?granularity=year",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"invalid granularity \"year\""}`,
+		},
+		{
+			name:         "Invalid Timezone",
+			alias:        "test-alias",
+			query:        "?tz=Mars/Olympus",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"invalid tz \"Mars/Olympus\""}`,
+		},
+		{
+			name:         "From After To",
+			alias:        "test-alias",
+			query:        "?from=2024-02-01&to=2024-01-01",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"from must be before to"}`,
+		},
+		{
+			name:         "Too Many Buckets",
+			alias:        "test-alias",
+			query:        "?from=2020-01-01&to=2024-01-01&granularity=hour",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"range has more than 2000 buckets, use a coarser granularity"}`,
+		},
+		{
+			name:         "Huge Range",
+			alias:        "test-alias",
+			query:        "?from=0001-01-01&to=9999-12-31&granularity=hour",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"range has more than 2000 buckets, use a coarser granularity"}`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockAnalyticsGetter := mocks.NewURLAnalyticsGetter(t)
 
-			if tt.alias != "" {
-				mockAnalyticsGetter.On("GetAnalytics", tt.alias).
+			if tt.alias != "" && tt.expectedCode != http.StatusBadRequest {
+				mockAnalyticsGetter.On("GetAnalytics", mock.Anything, tt.alias, mock.AnythingOfType("storage.AnalyticsQuery")).
 					Return(tt.mockAnalytics, tt.mockError).
 					Once()
 			}
 
 			recorder := httptest.NewRecorder()
 
-			req := httptest.NewRequest(http.MethodGet, "/analytics/"+tt.alias, nil)
+			req := httptest.NewRequest(http.MethodGet, "/analytics/"+tt.alias+tt.query, nil)
 
 			rctx := chi.NewRouteContext()
 			if tt.alias != "" {
@@ -105,3 +159,105 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2025, 8, 13, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		target   string
+		expected storage.AnalyticsQuery
+	}{
+		{
+			name:   "Defaults",
+			target: "/analytics/a",
+			expected: storage.AnalyticsQuery{
+				From:        time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC),
+				To:          now,
+				Granularity: timeseries.Day,
+				Location:    time.UTC,
+			},
+		},
+		{
+			name:   "Hourly RFC 3339",
+			target: "/analytics/a?granularity=hour&from=2025-08-13T10:00:00Z&to=2025-08-13T12:00:00Z",
+			expected: storage.AnalyticsQuery{
+				From:        time.Date(2025, 8, 13, 10, 0, 0, 0, time.UTC),
+				To:          time.Date(2025, 8, 13, 12, 0, 0, 0, time.UTC),
+				Granularity: timeseries.Hour,
+				Location:    time.UTC,
+			},
+		},
+		{
+			name:   "Monthly default window",
+			target: "/analytics/a?granularity=month",
+			expected: storage.AnalyticsQuery{
+				From:        time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+				To:          now,
+				Granularity: timeseries.Month,
+				Location:    time.UTC,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuery(httptest.NewRequest(http.MethodGet, tt.target, nil), now)
+			require.NoError(t, err)
+
+			assert.True(t, tt.expected.From.Equal(q.From), q.From)
+			assert.True(t, tt.expected.To.Equal(q.To), q.To)
+			assert.Equal(t, tt.expected.Granularity, q.Granularity)
+			assert.Equal(t, tt.expected.Location, q.Location)
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2025, 8, 13, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		target       string
+		expectedFrom time.Time
+		expectedTo   time.Time
+		expectedErr  string
+	}{
+		{
+			name:       "No default window",
+			target:     "/analytics/a/events",
+			expectedTo: now,
+		},
+		{
+			name:         "Dates in time zone",
+			target:       "/analytics/a/events?from=2025-08-01&to=2025-08-01&tz=Europe/Berlin",
+			expectedFrom: time.Date(2025, 7, 31, 22, 0, 0, 0, time.UTC),
+			expectedTo:   time.Date(2025, 8, 1, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "No bucket limit",
+			target:       "/analytics/a/events?from=0001-01-01&to=9999-12-31&granularity=hour",
+			expectedFrom: time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+			expectedTo:   time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Reversed",
+			target:      "/analytics/a/events?from=2025-08-13&to=2025-08-01",
+			expectedErr: "from must be before to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := ParseRange(httptest.NewRequest(http.MethodGet, tt.target, nil), now)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.True(t, tt.expectedFrom.Equal(from), from)
+			assert.True(t, tt.expectedTo.Equal(to), to)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const maxCompareAliases = 10
+
+type CompareResponse struct {
+	response.Response
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Granularity string        `json:"granularity"`
+	Timezone    string        `json:"timezone"`
+	Links       []LinkSummary `json:"links"`
+}
+
+type LinkSummary struct {
+	Alias          string           `json:"alias"`
+	TotalClicks    int64            `json:"total_clicks"`
+	UniqueVisitors int64            `json:"unique_visitors"`
+	Series         []Point          `json:"series"`
+	UserAgents     map[string]int64 `json:"user_agents"`
+	Referrers      []Breakdown      `json:"top_referrers"`
+	Sources        []Breakdown      `json:"top_sources"`
+	Countries      []Breakdown      `json:"top_countries"`
+	Regions        []Breakdown      `json:"top_regions"`
+	Cities         []Breakdown      `json:"top_cities"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=URLAnalyticsComparer
+type URLAnalyticsComparer interface {
+	CompareAnalytics(ctx context.Context, aliases []string, q storage.AnalyticsQuery) ([]storage.LinkAnalytics, error)
+}
+
+// NewCompare returns the analytics of the comma-separated links in the
+// "aliases" query parameter side by side, with series over the same buckets.
+func NewCompare(log *slog.Logger, comparer URLAnalyticsComparer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.analytics.NewCompare"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		aliases, err := parseAliases(r.URL.Query().Get("aliases"))
+		if err != nil {
+			log.Info("invalid aliases", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		q, err := ParseQuery(r, time.Now())
+		if err != nil {
+			log.Info("invalid analytics query", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		links, err := comparer.CompareAnalytics(r.Context(), aliases, q)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", sl.Err(err))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		if err != nil {
+			log.Error("failed to compare analytics", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		summaries := make([]LinkSummary, 0, len(links))
+		for _, l := range links {
+			summaries = append(summaries, LinkSummary{
+				Alias:          l.Alias,
+				TotalClicks:    l.TotalClicks,
+				UniqueVisitors: l.UniqueVisitors,
+				Series:         series(l.Series, q.Location),
+				UserAgents:     l.UserAgents,
+				Referrers:      breakdown(l.Referrers),
+				Sources:        breakdown(l.Sources),
+				Countries:      breakdown(l.Countries),
+				Regions:        breakdown(l.Regions),
+				Cities:         breakdown(l.Cities),
+			})
+		}
+
+		render.JSON(w, r, CompareResponse{
+			Response:    response.OK(),
+			From:        q.From.In(q.Location),
+			To:          q.To.In(q.Location),
+			Granularity: string(q.Granularity),
+			Timezone:    q.Location.String(),
+			Links:       summaries,
+		})
+	}
+}
+
+// parseAliases splits a comma-separated list, dropping blanks and repeats.
+func parseAliases(s string) ([]string, error) {
+	var aliases []string
+	for _, a := range strings.Split(s, ",") {
+		a = strings.TrimSpace(a)
+		if a != "" && !slices.Contains(aliases, a) {
+			aliases = append(aliases, a)
+		}
+	}
+
+	switch {
+	case len(aliases) == 0:
+		return nil, errors.New("aliases is required")
+	case len(aliases) > maxCompareAliases:
+		return nil, fmt.Errorf("at most %d aliases can be compared", maxCompareAliases)
+	}
+
+	return aliases, nil
+}
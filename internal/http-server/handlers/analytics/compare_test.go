@@ -0,0 +1,123 @@
+package analytics
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/analytics/mocks"
+	"analiticsURLShortener/internal/storage"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewCompare(t *testing.T) {
+	day := time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		query        string
+		aliases      []string
+		mockLinks    []storage.LinkAnalytics
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:    "Success",
+			query:   "?aliases=a,b,a&from=2025-08-11&to=2025-08-11",
+			aliases: []string{"a", "b"},
+			mockLinks: []storage.LinkAnalytics{
+				{Alias: "a", AnalyticsData: storage.AnalyticsData{
+					TotalClicks: 3, UniqueVisitors: 2,
+					Series:     []storage.Point{{Time: day, Clicks: 3, Uniques: 2}},
+					UserAgents: map[string]int64{"curl": 3},
+					Referrers:  []storage.Count{{Value: "direct", Clicks: 3}},
+				}},
+				{Alias: "b", AnalyticsData: storage.AnalyticsData{
+					Series:     []storage.Point{{Time: day}},
+					UserAgents: map[string]int64{},
+				}},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{
+				"status": "OK",
+				"from": "2025-08-11T00:00:00Z",
+				"to": "2025-08-12T00:00:00Z",
+				"granularity": "day",
+				"timezone": "UTC",
+				"links": [
+					{
+						"alias": "a", "total_clicks": 3, "unique_visitors": 2,
+						"series": [{"time": "2025-08-11T00:00:00Z", "clicks": 3, "uniques": 2}],
+						"user_agents": {"curl": 3},
+						"top_referrers": [{"value": "direct", "clicks": 3}],
+						"top_sources": [], "top_countries": [], "top_regions": [], "top_cities": []
+					},
+					{
+						"alias": "b", "total_clicks": 0, "unique_visitors": 0,
+						"series": [{"time": "2025-08-11T00:00:00Z", "clicks": 0, "uniques": 0}],
+						"user_agents": {},
+						"top_referrers": [], "top_sources": [], "top_countries": [], "top_regions": [], "top_cities": []
+					}
+				]
+			}`,
+		},
+		{
+			name:         "No aliases",
+			query:        "?aliases=,",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status": "Error", "error": "aliases is required"}`,
+		},
+		{
+			name:         "Too many aliases",
+			query:        "?aliases=a,b,c,d,e,f,g,h,i,j,k",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status": "Error", "error": "at most 10 aliases can be compared"}`,
+		},
+		{
+			name:         "Invalid range",
+			query:        "?aliases=a&granularity=year",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status": "Error", "error": "invalid granularity \"year\""}`,
+		},
+		{
+			name:         "Unknown alias",
+			query:        "?aliases=a,missing",
+			aliases:      []string{"a", "missing"},
+			mockError:    fmt.Errorf("%w: %s", storage.ErrURLNotFound, "missing"),
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"status": "Error", "error": "URL not found: missing"}`,
+		},
+		{
+			name:         "Storage error",
+			query:        "?aliases=a",
+			aliases:      []string{"a"},
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status": "Error", "error": "internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparer := mocks.NewURLAnalyticsComparer(t)
+			if tt.aliases != nil {
+				comparer.On("CompareAnalytics", mock.Anything, tt.aliases, mock.AnythingOfType("storage.AnalyticsQuery")).
+					Return(tt.mockLinks, tt.mockError).
+					Once()
+			}
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/analytics"+tt.query, nil)
+			NewCompare(slog.Default(), comparer).ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package events
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/http-server/handlers/analytics"
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+
+	// flushEvery is how many events are buffered before they are sent to the
+	// client; for Parquet it is also the row group size.
+	flushEvery = 10000
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=ClickStreamer
+type ClickStreamer interface {
+	StreamClicks(ctx context.Context, alias string, from, to time.Time, fn func(storage.ClickEvent) error) error
+}
+
+// New streams the raw click events of a link in [from, to) as CSV, NDJSON or
+// Parquet. Time range parameters are the same as for the analytics endpoint,
+// but without from the export starts at the first click. Exports running
+// longer than cfg.MaxDuration are cut off.
+func New(log *slog.Logger, clickStreamer ClickStreamer, cfg config.Export) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.analytics.events.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "short_url")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatCSV
+		}
+		if format != FormatCSV && format != FormatNDJSON && format != FormatParquet {
+			log.Info("invalid format", slog.String("format", format))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("format must be csv, ndjson or parquet"))
+			return
+		}
+
+		from, to, err := analytics.ParseRange(r, time.Now())
+		if err != nil {
+			log.Info("invalid events query", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		// exports can take longer than the server write timeout, so every
+		// batch gets its own
+		rc := http.NewResponseController(w)
+		extendDeadline := func() {
+			var deadline time.Time
+			if cfg.WriteTimeout > 0 {
+				deadline = time.Now().Add(cfg.WriteTimeout)
+			}
+			_ = rc.SetWriteDeadline(deadline)
+		}
+		extendDeadline()
+
+		ctx := r.Context()
+		if cfg.MaxDuration > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.MaxDuration)
+			defer cancel()
+		}
+
+		var enc encoder
+		var count int
+		err = clickStreamer.StreamClicks(ctx, alias, from, to, func(e storage.ClickEvent) error {
+			if enc == nil {
+				enc = start(w, alias, format)
+			}
+
+			if err := enc.Write(e); err != nil {
+				return err
+			}
+
+			count++
+			if count%flushEvery == 0 {
+				if err := enc.Flush(); err != nil {
+					return err
+				}
+				extendDeadline()
+			}
+
+			return nil
+		})
+		if err != nil && enc == nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Info("url not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("not found"))
+				return
+			}
+
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Warn("click export took too long", slog.Duration("max_duration", cfg.MaxDuration))
+			} else {
+				log.Error("failed to stream clicks", sl.Err(err))
+			}
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+		if err != nil {
+			// the status is already sent, so abort the connection to let the
+			// client see that the export is incomplete
+			log.Error("click export interrupted", sl.Err(err), slog.Int("events", count))
+			panic(http.ErrAbortHandler)
+		}
+
+		if enc == nil {
+			enc = start(w, alias, format)
+		}
+
+		if err := enc.Close(); err != nil {
+			log.Error("failed to finish click export", sl.Err(err))
+			panic(http.ErrAbortHandler)
+		}
+
+		log.Info("clicks exported", slog.String("format", format), slog.Int("events", count))
+	}
+}
+
+func start(w http.ResponseWriter, alias, format string) encoder {
+	contentType := map[string]string{
+		FormatCSV:     "text/csv; charset=utf-8",
+		FormatNDJSON:  "application/x-ndjson",
+		FormatParquet: "application/vnd.apache.parquet",
+	}[format]
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": alias + "-clicks." + format,
+	}))
+	w.WriteHeader(http.StatusOK)
+
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonEncoder{w: w, enc: json.NewEncoder(w)}
+	case FormatParquet:
+		return &parquetEncoder{w: w, pw: parquet.NewGenericWriter[parquetRow](w)}
+	default:
+		return &csvEncoder{w: w, cw: csv.NewWriter(w)}
+	}
+}
+
+type encoder interface {
+	Write(e storage.ClickEvent) error
+	Flush() error
+	Close() error
+}
+
+func flush(w io.Writer) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+var csvHeader = []string{"time", "user_agent", "referrer", "source", "country", "region", "city", "ip", "visitor_id"}
+
+type csvEncoder struct {
+	w             io.Writer
+	cw            *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvEncoder) Write(ev storage.ClickEvent) error {
+	if !e.headerWritten {
+		if err := e.cw.Write(csvHeader); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+
+	return e.cw.Write([]string{
+		ev.Time.UTC().Format(time.RFC3339Nano),
+		ev.UserAgent,
+		ev.Referrer,
+		ev.Source,
+		ev.Country,
+		ev.Region,
+		ev.City,
+		ev.IP,
+		ev.VisitorID,
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.cw.Flush()
+	flush(e.w)
+
+	return e.cw.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	if !e.headerWritten {
+		if err := e.cw.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	return e.Flush()
+}
+
+type jsonEvent struct {
+	Time      time.Time `json:"time"`
+	UserAgent string    `json:"user_agent"`
+	Referrer  string    `json:"referrer"`
+	Source    string    `json:"source"`
+	Country   string    `json:"country"`
+	Region    string    `json:"region"`
+	City      string    `json:"city"`
+	IP        string    `json:"ip,omitempty"`
+	VisitorID string    `json:"visitor_id"`
+}
+
+type ndjsonEncoder struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Write(ev storage.ClickEvent) error {
+	return e.enc.Encode(jsonEvent{
+		Time:      ev.Time.UTC(),
+		UserAgent: ev.UserAgent,
+		Referrer:  ev.Referrer,
+		Source:    ev.Source,
+		Country:   ev.Country,
+		Region:    ev.Region,
+		City:      ev.City,
+		IP:        ev.IP,
+		VisitorID: ev.VisitorID,
+	})
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	flush(e.w)
+	return nil
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return e.Flush()
+}
+
+type parquetRow struct {
+	Time      time.Time `parquet:"time,timestamp(microsecond)"`
+	UserAgent string    `parquet:"user_agent,dict"`
+	Referrer  string    `parquet:"referrer,dict"`
+	Source    string    `parquet:"source,dict"`
+	Country   string    `parquet:"country,dict"`
+	Region    string    `parquet:"region,dict"`
+	City      string    `parquet:"city,dict"`
+	IP        string    `parquet:"ip,optional"`
+	VisitorID string    `parquet:"visitor_id"`
+}
+
+type parquetEncoder struct {
+	w    io.Writer
+	pw   *parquet.GenericWriter[parquetRow]
+	rows []parquetRow
+}
+
+func (e *parquetEncoder) Write(ev storage.ClickEvent) error {
+	e.rows = append(e.rows, parquetRow{
+		Time:      ev.Time.UTC(),
+		UserAgent: ev.UserAgent,
+		Referrer:  ev.Referrer,
+		Source:    ev.Source,
+		Country:   ev.Country,
+		Region:    ev.Region,
+		City:      ev.City,
+		IP:        ev.IP,
+		VisitorID: ev.VisitorID,
+	})
+
+	return nil
+}
+
+// Flush writes the buffered rows as one row group.
+func (e *parquetEncoder) Flush() error {
+	if len(e.rows) > 0 {
+		if _, err := e.pw.Write(e.rows); err != nil {
+			return err
+		}
+		e.rows = e.rows[:0]
+
+		if err := e.pw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	flush(e.w)
+
+	return nil
+}
+
+func (e *parquetEncoder) Close() error {
+	if len(e.rows) > 0 {
+		if _, err := e.pw.Write(e.rows); err != nil {
+			return err
+		}
+	}
+
+	if err := e.pw.Close(); err != nil {
+		return err
+	}
+
+	flush(e.w)
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package events
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/http-server/handlers/analytics/events/mocks"
+	"analiticsURLShortener/internal/storage"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvents = []storage.ClickEvent{
+	{
+		Alias: "promo",
+		Time:  time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC),
+		Click: storage.Click{UserAgent: "Mozilla/5.0", Referrer: "google.com", Country: "DE", VisitorID: "abc"},
+	},
+	{
+		Alias: "promo",
+		Time:  time.Date(2025, 8, 11, 11, 30, 0, 0, time.UTC),
+		Click: storage.Click{UserAgent: "curl/8.0", Source: "qr"},
+	},
+}
+
+func streamEvents(events []storage.ClickEvent, err error) func(context.Context, string, time.Time, time.Time, func(storage.ClickEvent) error) error {
+	return func(_ context.Context, _ string, _, _ time.Time, fn func(storage.ClickEvent) error) error {
+		for _, e := range events {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+}
+
+func serve(t *testing.T, streamer ClickStreamer, alias, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/analytics/"+alias+"/events"+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("short_url", alias)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	recorder := httptest.NewRecorder()
+	New(slog.Default(), streamer, config.Export{}).ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func TestNew_Formats(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		events       []storage.ClickEvent
+		expectedType string
+		expectedBody string
+	}{
+		{
+			name:         "CSV",
+			format:       "csv",
+			events:       testEvents,
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "time,user_agent,referrer,source,country,region,city,ip,visitor_id\n" +
+				"2025-08-11T10:00:00Z,Mozilla/5.0,google.com,,DE,,,,abc\n" +
+				"2025-08-11T11:30:00Z,curl/8.0,,qr,,,,,\n",
+		},
+		{
+			name:         "Empty CSV",
+			format:       "",
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "time,user_agent,referrer,source,country,region,city,ip,visitor_id\n",
+		},
+		{
+			name:         "NDJSON",
+			format:       "ndjson",
+			events:       testEvents,
+			expectedType: "application/x-ndjson",
+			expectedBody: `{"time":"2025-08-11T10:00:00Z","user_agent":"Mozilla/5.0","referrer":"google.com","source":"","country":"DE","region":"","city":"","visitor_id":"abc"}` + "\n" +
+				`{"time":"2025-08-11T11:30:00Z","user_agent":"curl/8.0","referrer":"","source":"qr","country":"","region":"","city":"","visitor_id":""}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamer := mocks.NewClickStreamer(t)
+			streamer.On("StreamClicks", mock.Anything, "promo", mock.Anything, mock.Anything, mock.Anything).
+				Return(streamEvents(tt.events, nil)).Once()
+
+			recorder := serve(t, streamer, "promo", "?from=2025-08-01&format="+tt.format)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tt.expectedType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestNew_Parquet(t *testing.T) {
+	streamer := mocks.NewClickStreamer(t)
+	streamer.On("StreamClicks", mock.Anything, "promo", mock.Anything, mock.Anything, mock.Anything).
+		Return(streamEvents(testEvents, nil)).Once()
+
+	recorder := serve(t, streamer, "promo", "?from=2025-08-01&format=parquet")
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "attachment; filename=promo-clicks.parquet", recorder.Header().Get("Content-Disposition"))
+
+	body := recorder.Body.Bytes()
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "google.com", rows[0].Referrer)
+	assert.True(t, testEvents[1].Time.Equal(rows[1].Time))
+}
+
+func TestNew_Range(t *testing.T) {
+	streamer := mocks.NewClickStreamer(t)
+	streamer.On("StreamClicks", mock.Anything, "promo", time.Time{}, mock.Anything, mock.Anything).
+		Return(streamEvents(nil, nil)).Once()
+
+	recorder := serve(t, streamer, "promo", "?to=9999-12-31&granularity=hour")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestNew_Filename(t *testing.T) {
+	streamer := mocks.NewClickStreamer(t)
+	streamer.On("StreamClicks", mock.Anything, `a"b`, mock.Anything, mock.Anything, mock.Anything).
+		Return(streamEvents(nil, nil)).Once()
+
+	recorder := serve(t, streamer, `a"b`, "")
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `attachment; filename="a\"b-clicks.csv"`, recorder.Header().Get("Content-Disposition"))
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Invalid format",
+			query:        "?format=xlsx",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"format must be csv, ndjson or parquet"}` + "\n",
+		},
+		{
+			name:         "Invalid range",
+			query:        "?from=yesterday",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"invalid from \"yesterday\""}` + "\n",
+		},
+		{
+			name:         "URL Not Found",
+			mockError:    storage.ErrURLNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"status":"Error","error":"not found"}` + "\n",
+		},
+		{
+			name:         "Internal Error",
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status":"Error","error":"internal error"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamer := mocks.NewClickStreamer(t)
+			if tt.mockError != nil {
+				streamer.On("StreamClicks", mock.Anything, "promo", mock.Anything, mock.Anything, mock.Anything).
+					Return(tt.mockError).Once()
+			}
+
+			recorder := serve(t, streamer, "promo", tt.query)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestNew_InterruptedStream(t *testing.T) {
+	streamer := mocks.NewClickStreamer(t)
+	streamer.On("StreamClicks", mock.Anything, "promo", mock.Anything, mock.Anything, mock.Anything).
+		Return(streamEvents(testEvents, errors.New("connection reset"))).Once()
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		serve(t, streamer, "promo", "?format=ndjson")
+	})
+}
+
+func TestNew_MaxDuration(t *testing.T) {
+	streamer := mocks.NewClickStreamer(t)
+	streamer.On("StreamClicks", mock.Anything, "promo", mock.Anything, mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, _ string, _, _ time.Time, fn func(storage.ClickEvent) error) error {
+			if err := fn(testEvents[0]); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		}).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/analytics/promo/events?format=ndjson", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("short_url", "promo")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	handler := New(slog.Default(), streamer, config.Export{MaxDuration: 10 * time.Millisecond})
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
@@ -0,0 +1,50 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "analiticsURLShortener/internal/storage"
+
+	time "time"
+)
+
+// ClickStreamer is an autogenerated mock type for the ClickStreamer type
+type ClickStreamer struct {
+	mock.Mock
+}
+
+// StreamClicks provides a mock function with given fields: ctx, alias, from, to, fn
+func (_m *ClickStreamer) StreamClicks(ctx context.Context, alias string, from time.Time, to time.Time, fn func(storage.ClickEvent) error) error {
+	ret := _m.Called(ctx, alias, from, to, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamClicks")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time, func(storage.ClickEvent) error) error); ok {
+		r0 = rf(ctx, alias, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewClickStreamer creates a new instance of ClickStreamer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClickStreamer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ClickStreamer {
+	mock := &ClickStreamer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,127 @@
+package heatmap
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/analytics"
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/chart"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	FormatJSON = "json"
+	FormatSVG  = "svg"
+)
+
+var weekdays = [7]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+type Response struct {
+	response.Response
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Timezone    string    `json:"timezone"`
+	TotalClicks int64     `json:"total_clicks"`
+	Days        []Day     `json:"days"`
+}
+
+type Day struct {
+	Day   string  `json:"day"`
+	Hours []int64 `json:"hours"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=HeatmapGetter
+type HeatmapGetter interface {
+	GetHeatmap(ctx context.Context, alias string, q storage.AnalyticsQuery) (storage.Heatmap, error)
+}
+
+// New returns clicks of a link by day of week and hour of day in the tz of
+// the request, as JSON or, with format=svg or a .svg suffix, as an image.
+func New(log *slog.Logger, heatmapGetter HeatmapGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.analytics.heatmap.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "short_url")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if ext, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); ext != "" {
+			format = ext
+		}
+		if format == "" {
+			format = FormatJSON
+		}
+		if format != FormatJSON && format != FormatSVG {
+			log.Info("invalid format", slog.String("format", format))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("format must be json or svg"))
+			return
+		}
+
+		q, err := analytics.ParseQuery(r, time.Now())
+		if err != nil {
+			log.Info("invalid heatmap query", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		heatmap, err := heatmapGetter.GetHeatmap(r.Context(), alias, q)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get heatmap", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		if format == FormatSVG {
+			w.Header().Set("Content-Type", "image/svg+xml")
+			if err := chart.Heatmap(w, heatmap); err != nil {
+				log.Info("failed to write heatmap", sl.Err(err))
+			}
+			return
+		}
+
+		resp := Response{
+			Response: response.OK(),
+			From:     q.From.In(q.Location),
+			To:       q.To.In(q.Location),
+			Timezone: q.Location.String(),
+			Days:     make([]Day, 0, len(weekdays)),
+		}
+		for d, hours := range heatmap {
+			for _, clicks := range hours {
+				resp.TotalClicks += clicks
+			}
+			resp.Days = append(resp.Days, Day{Day: weekdays[d], Hours: hours[:]})
+		}
+
+		render.JSON(w, r, resp)
+	}
+}
@@ -0,0 +1,102 @@
+package heatmap
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/analytics/heatmap/mocks"
+	"analiticsURLShortener/internal/storage"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, getter HeatmapGetter, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	router := chi.NewRouter()
+	router.Use(middleware.URLFormat)
+	router.Get("/analytics/{short_url}/heatmap", New(slog.Default(), getter))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	return recorder
+}
+
+func TestNew_JSON(t *testing.T) {
+	var heatmap storage.Heatmap
+	heatmap[0][9] = 4
+	heatmap[6][23] = 1
+
+	getter := mocks.NewHeatmapGetter(t)
+	getter.On("GetHeatmap", mock.Anything, "promo", mock.MatchedBy(func(q storage.AnalyticsQuery) bool {
+		return q.Location.String() == "Europe/Berlin"
+	})).Return(heatmap, nil).Once()
+
+	recorder := serve(t, getter, "/analytics/promo/heatmap?from=2025-08-04&to=2025-08-10&tz=Europe/Berlin")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+
+	assert.Equal(t, "Europe/Berlin", resp.Timezone)
+	assert.True(t, time.Date(2025, 8, 3, 22, 0, 0, 0, time.UTC).Equal(resp.From), resp.From)
+	assert.Equal(t, int64(5), resp.TotalClicks)
+	require.Len(t, resp.Days, 7)
+	assert.Equal(t, "monday", resp.Days[0].Day)
+	assert.Equal(t, int64(4), resp.Days[0].Hours[9])
+	assert.Equal(t, "sunday", resp.Days[6].Day)
+	assert.Equal(t, int64(1), resp.Days[6].Hours[23])
+	assert.Len(t, resp.Days[3].Hours, 24)
+}
+
+func TestNew_SVG(t *testing.T) {
+	for _, target := range []string{"/analytics/promo/heatmap.svg", "/analytics/promo/heatmap?format=svg"} {
+		t.Run(target, func(t *testing.T) {
+			getter := mocks.NewHeatmapGetter(t)
+			getter.On("GetHeatmap", mock.Anything, "promo", mock.AnythingOfType("storage.AnalyticsQuery")).
+				Return(storage.Heatmap{}, nil).Once()
+
+			recorder := serve(t, getter, target)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "image/svg+xml", recorder.Header().Get("Content-Type"))
+			assert.Contains(t, recorder.Body.String(), "<svg")
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		mockError    error
+		expectedCode int
+	}{
+		{name: "Not found", target: "/analytics/missing/heatmap", mockError: storage.ErrURLNotFound, expectedCode: http.StatusNotFound},
+		{name: "Storage error", target: "/analytics/promo/heatmap", mockError: errors.New("db error"), expectedCode: http.StatusInternalServerError},
+		{name: "Invalid format", target: "/analytics/promo/heatmap?format=png", expectedCode: http.StatusBadRequest},
+		{name: "Invalid tz", target: "/analytics/promo/heatmap?tz=Mars/Olympus", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := mocks.NewHeatmapGetter(t)
+			if tt.mockError != nil {
+				getter.On("GetHeatmap", mock.Anything, mock.Anything, mock.AnythingOfType("storage.AnalyticsQuery")).
+					Return(storage.Heatmap{}, tt.mockError).Once()
+			}
+
+			recorder := serve(t, getter, tt.target)
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "analiticsURLShortener/internal/storage"
+)
+
+// HeatmapGetter is an autogenerated mock type for the HeatmapGetter type
+type HeatmapGetter struct {
+	mock.Mock
+}
+
+// GetHeatmap provides a mock function with given fields: ctx, alias, q
+func (_m *HeatmapGetter) GetHeatmap(ctx context.Context, alias string, q storage.AnalyticsQuery) (storage.Heatmap, error) {
+	ret := _m.Called(ctx, alias, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHeatmap")
+	}
+
+	var r0 storage.Heatmap
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.AnalyticsQuery) (storage.Heatmap, error)); ok {
+		return rf(ctx, alias, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.AnalyticsQuery) storage.Heatmap); ok {
+		r0 = rf(ctx, alias, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.Heatmap)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, storage.AnalyticsQuery) error); ok {
+		r1 = rf(ctx, alias, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewHeatmapGetter creates a new instance of HeatmapGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewHeatmapGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *HeatmapGetter {
+	mock := &HeatmapGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
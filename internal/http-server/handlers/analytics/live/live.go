@@ -0,0 +1,197 @@
+package live
+
+import (
+	"analiticsURLShortener/internal/clicks"
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+type Event struct {
+	Time      time.Time `json:"time"`
+	UserAgent string    `json:"user_agent"`
+	Referrer  string    `json:"referrer"`
+	Source    string    `json:"source"`
+	Country   string    `json:"country"`
+	Region    string    `json:"region"`
+	City      string    `json:"city"`
+	VisitorID string    `json:"visitor_id"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=URLGetter
+type URLGetter interface {
+	GetURL(ctx context.Context, alias string) (string, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=ClickSubscriber
+type ClickSubscriber interface {
+	Subscribe(domain, alias string, policy clicks.Policy) (*clicks.Subscription, error)
+	Unsubscribe(sub *clicks.Subscription)
+}
+
+// New streams the clicks of a link as Server-Sent Events while they are
+// ingested. The "policy" query parameter overrides the configured
+// backpressure policy for this connection.
+func New(log *slog.Logger, urlGetter URLGetter, subscriber ClickSubscriber, cfg config.Live) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.analytics.live.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "short_url")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		policyParam := r.URL.Query().Get("policy")
+		if policyParam == "" {
+			policyParam = cfg.Policy
+		}
+		policy, err := clicks.ParsePolicy(policyParam)
+		if err != nil {
+			log.Info("invalid policy", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("policy must be drop_oldest, drop_newest or disconnect"))
+			return
+		}
+
+		_, err = urlGetter.GetURL(r.Context(), alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		sub, err := subscriber.Subscribe(storage.DomainFromContext(r.Context()), alias, policy)
+		if errors.Is(err, clicks.ErrTooManySubscribers) {
+			log.Warn("too many live subscribers")
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, response.Error("too many subscribers"))
+			return
+		}
+		if errors.Is(err, clicks.ErrHubClosed) {
+			log.Info("live stream refused during shutdown")
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, response.Error("shutting down"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to subscribe", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+		defer subscriber.Unsubscribe(sub)
+
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		_ = rc.Flush()
+
+		log.Info("live stream started", slog.String("alias", alias), slog.String("policy", string(policy)))
+
+		interval := cfg.HeartbeatInterval
+		if interval <= 0 {
+			interval = defaultHeartbeat
+		}
+		heartbeat := time.NewTicker(interval)
+		defer heartbeat.Stop()
+
+		var reported int64
+		for {
+			var err error
+
+			select {
+			case <-r.Context().Done():
+				log.Info("live stream closed by client")
+				return
+			case <-sub.Done():
+				reason := "slow consumer"
+				if errors.Is(sub.Err(), clicks.ErrHubClosed) {
+					reason = "shutdown"
+				}
+				_ = writeEvent(w, "disconnect", map[string]string{"reason": reason})
+				_ = rc.Flush()
+				log.Info("live subscriber disconnected", slog.String("reason", reason))
+				return
+			case <-heartbeat.C:
+				_, err = io.WriteString(w, ": ping\n\n")
+			case e := <-sub.Events():
+				if dropped := sub.Dropped(); dropped > reported {
+					reported = dropped
+					err = writeEvent(w, "dropped", map[string]int64{"total": dropped})
+				}
+				if err == nil {
+					err = writeEvent(w, "click", toEvent(e))
+				}
+			}
+
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				log.Info("live stream write failed", sl.Err(err))
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w io.Writer, name string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b)
+
+	return err
+}
+
+func toEvent(e storage.ClickEvent) Event {
+	return Event{
+		Time:      e.Time.UTC(),
+		UserAgent: e.UserAgent,
+		Referrer:  e.Referrer,
+		Source:    e.Source,
+		Country:   e.Country,
+		Region:    e.Region,
+		City:      e.City,
+		VisitorID: e.VisitorID,
+	}
+}
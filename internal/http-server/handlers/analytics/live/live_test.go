@@ -0,0 +1,229 @@
+package live
+
+import (
+	"analiticsURLShortener/internal/clicks"
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/http-server/handlers/analytics/live/mocks"
+	"analiticsURLShortener/internal/storage"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testCfg = config.Live{
+	MaxSubscribers:    1,
+	BufferSize:        1,
+	Policy:            "drop_oldest",
+	HeartbeatInterval: time.Hour,
+}
+
+func serve(t *testing.T, getter URLGetter, hub *clicks.Hub) *httptest.Server {
+	t.Helper()
+
+	router := chi.NewRouter()
+	router.Get("/analytics/{short_url}/live", New(slog.Default(), getter, hub, testCfg))
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return e
+		case strings.HasPrefix(line, "event: "):
+			e.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		alias        string
+		query        string
+		getErr       error
+		subscribed   bool
+		expectedCode int
+	}{
+		{
+			name:         "Not found",
+			alias:        "missing",
+			getErr:       storage.ErrURLNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Storage error",
+			alias:        "promo",
+			getErr:       errors.New("db is down"),
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:         "Invalid policy",
+			alias:        "promo",
+			query:        "?policy=block",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Too many subscribers",
+			alias:        "promo",
+			subscribed:   true,
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			getter := mocks.NewURLGetter(t)
+			if tc.query == "" {
+				getter.On("GetURL", mock.Anything, tc.alias).Return("https://example.com", tc.getErr).Once()
+			}
+
+			hub := clicks.NewHub(testCfg)
+			if tc.subscribed {
+				_, err := hub.Subscribe("", "other", clicks.DropOldest)
+				require.NoError(t, err)
+			}
+
+			srv := serve(t, getter, hub)
+
+			resp, err := http.Get(srv.URL + "/analytics/" + tc.alias + "/live" + tc.query)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestNew_Stream(t *testing.T) {
+	getter := mocks.NewURLGetter(t)
+	getter.On("GetURL", mock.Anything, "promo").Return("https://example.com", nil).Once()
+
+	hub := clicks.NewHub(testCfg)
+	srv := serve(t, getter, hub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/analytics/promo/live", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The handler subscribes before writing headers, so the event is not lost
+	hub.Publish(storage.ClickEvent{
+		Alias: "promo",
+		Time:  time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC),
+		Click: storage.Click{UserAgent: "Mozilla/5.0", Referrer: "google.com", Country: "DE", IP: "203.0.113.7", VisitorID: "abc"},
+	})
+	hub.Publish(storage.ClickEvent{Alias: "other", Time: time.Now()})
+
+	r := bufio.NewReader(resp.Body)
+	e := readEvent(t, r)
+	assert.Equal(t, "click", e.name)
+	assert.NotContains(t, e.data, "203.0.113.7")
+
+	var got Event
+	require.NoError(t, json.Unmarshal([]byte(e.data), &got))
+	assert.Equal(t, Event{
+		Time:      time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC),
+		UserAgent: "Mozilla/5.0",
+		Referrer:  "google.com",
+		Country:   "DE",
+		VisitorID: "abc",
+	}, got)
+}
+
+func TestNew_Disconnect(t *testing.T) {
+	getter := mocks.NewURLGetter(t)
+	getter.On("GetURL", mock.Anything, "promo").Return("https://example.com", nil).Once()
+
+	hub := clicks.NewHub(testCfg)
+	srv := serve(t, getter, hub)
+
+	resp, err := http.Get(srv.URL + "/analytics/promo/live?policy=disconnect")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The handler writes to the network after every event, so a burst
+	// overflows the one-event buffer long before it catches up
+	for i := 0; i < 1000; i++ {
+		hub.Publish(storage.ClickEvent{Alias: "promo", Time: time.Now()})
+	}
+
+	r := bufio.NewReader(resp.Body)
+	for {
+		e := readEvent(t, r)
+		if e.name == "disconnect" {
+			break
+		}
+		assert.Equal(t, "click", e.name)
+	}
+
+	// The slot is freed once the handler returns
+	require.Eventually(t, func() bool {
+		sub, err := hub.Subscribe("", "promo", clicks.DropOldest)
+		if err != nil {
+			return false
+		}
+		hub.Unsubscribe(sub)
+		return true
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNew_Shutdown(t *testing.T) {
+	getter := mocks.NewURLGetter(t)
+	getter.On("GetURL", mock.Anything, "promo").Return("https://example.com", nil).Once()
+
+	hub := clicks.NewHub(testCfg)
+	srv := serve(t, getter, hub)
+
+	resp, err := http.Get(srv.URL + "/analytics/promo/live")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	hub.Close()
+
+	e := readEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "disconnect", e.name)
+	assert.JSONEq(t, `{"reason":"shutdown"}`, e.data)
+}
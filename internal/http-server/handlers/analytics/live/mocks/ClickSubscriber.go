@@ -0,0 +1,63 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	clicks "analiticsURLShortener/internal/clicks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ClickSubscriber is an autogenerated mock type for the ClickSubscriber type
+type ClickSubscriber struct {
+	mock.Mock
+}
+
+// Subscribe provides a mock function with given fields: domain, alias, policy
+func (_m *ClickSubscriber) Subscribe(domain string, alias string, policy clicks.Policy) (*clicks.Subscription, error) {
+	ret := _m.Called(domain, alias, policy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscribe")
+	}
+
+	var r0 *clicks.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, clicks.Policy) (*clicks.Subscription, error)); ok {
+		return rf(domain, alias, policy)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, clicks.Policy) *clicks.Subscription); ok {
+		r0 = rf(domain, alias, policy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*clicks.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, clicks.Policy) error); ok {
+		r1 = rf(domain, alias, policy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unsubscribe provides a mock function with given fields: sub
+func (_m *ClickSubscriber) Unsubscribe(sub *clicks.Subscription) {
+	_m.Called(sub)
+}
+
+// NewClickSubscriber creates a new instance of ClickSubscriber. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClickSubscriber(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ClickSubscriber {
+	mock := &ClickSubscriber{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,59 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "analiticsURLShortener/internal/storage"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// URLAnalyticsComparer is an autogenerated mock type for the URLAnalyticsComparer type
+type URLAnalyticsComparer struct {
+	mock.Mock
+}
+
+// CompareAnalytics provides a mock function with given fields: ctx, aliases, q
+func (_m *URLAnalyticsComparer) CompareAnalytics(ctx context.Context, aliases []string, q storage.AnalyticsQuery) ([]storage.LinkAnalytics, error) {
+	ret := _m.Called(ctx, aliases, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompareAnalytics")
+	}
+
+	var r0 []storage.LinkAnalytics
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, storage.AnalyticsQuery) ([]storage.LinkAnalytics, error)); ok {
+		return rf(ctx, aliases, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, storage.AnalyticsQuery) []storage.LinkAnalytics); ok {
+		r0 = rf(ctx, aliases, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.LinkAnalytics)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, storage.AnalyticsQuery) error); ok {
+		r1 = rf(ctx, aliases, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLAnalyticsComparer creates a new instance of URLAnalyticsComparer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLAnalyticsComparer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLAnalyticsComparer {
+	mock := &URLAnalyticsComparer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	storage "analiticsURLShortener/internal/storage"
+	context "context"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,9 +14,9 @@ type URLAnalyticsGetter struct {
 	mock.Mock
 }
 
-// GetAnalytics provides a mock function with given fields: alias
-func (_m *URLAnalyticsGetter) GetAnalytics(alias string) (storage.AnalyticsData, error) {
-	ret := _m.Called(alias)
+// GetAnalytics provides a mock function with given fields: ctx, alias, q
+func (_m *URLAnalyticsGetter) GetAnalytics(ctx context.Context, alias string, q storage.AnalyticsQuery) (storage.AnalyticsData, error) {
+	ret := _m.Called(ctx, alias, q)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAnalytics")
@@ -23,17 +24,17 @@ func (_m *URLAnalyticsGetter) GetAnalytics(alias string) (storage.AnalyticsData,
 
 	var r0 storage.AnalyticsData
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (storage.AnalyticsData, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.AnalyticsQuery) (storage.AnalyticsData, error)); ok {
+		return rf(ctx, alias, q)
 	}
-	if rf, ok := ret.Get(0).(func(string) storage.AnalyticsData); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.AnalyticsQuery) storage.AnalyticsData); ok {
+		r0 = rf(ctx, alias, q)
 	} else {
 		r0 = ret.Get(0).(storage.AnalyticsData)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+	if rf, ok := ret.Get(1).(func(context.Context, string, storage.AnalyticsQuery) error); ok {
+		r1 = rf(ctx, alias, q)
 	} else {
 		r1 = ret.Error(1)
 	}
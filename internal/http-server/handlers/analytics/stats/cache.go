@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"analiticsURLShortener/internal/lib/ttlcache"
+	"analiticsURLShortener/internal/storage"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// cacheEntries bounds the distinct queries cached at once.
+const cacheEntries = 256
+
+// Cache shares global stats between /stats and the dashboard overview.
+// Entries are keyed by the domain of the request and its from, to,
+// granularity and tz parameters rather than by the parsed query, whose range
+// moves with the clock when to is omitted.
+type Cache struct {
+	getter GlobalStatsGetter
+	cache  *ttlcache.Cache[string, cached]
+}
+
+type cached struct {
+	q     storage.AnalyticsQuery
+	stats storage.GlobalStats
+}
+
+// NewCache caches the stats of getter for ttl; 0 disables caching.
+func NewCache(getter GlobalStatsGetter, ttl time.Duration) *Cache {
+	return &Cache{getter: getter, cache: ttlcache.New[string, cached](ttl, cacheEntries)}
+}
+
+// Get returns the stats of q, parsed from r, with the limit most clicked
+// links. A cached result comes with the query it was computed for.
+func (c *Cache) Get(r *http.Request, q storage.AnalyticsQuery, limit int) (storage.AnalyticsQuery, storage.GlobalStats, error) {
+	params := r.URL.Query()
+	// Encode sorts the parameters, so equal queries share an entry
+	key := storage.DomainFromContext(r.Context()) + "?" + url.Values{
+		"from":        {params.Get("from")},
+		"to":          {params.Get("to")},
+		"granularity": {params.Get("granularity")},
+		"tz":          {params.Get("tz")},
+		"limit":       {strconv.Itoa(limit)},
+	}.Encode()
+
+	if e, ok := c.cache.Get(key); ok {
+		return e.q, e.stats, nil
+	}
+
+	stats, err := c.getter.GetGlobalStats(r.Context(), q, limit)
+	if err != nil {
+		return storage.AnalyticsQuery{}, storage.GlobalStats{}, err
+	}
+	c.cache.Set(key, cached{q: q, stats: stats})
+
+	return q, stats, nil
+}
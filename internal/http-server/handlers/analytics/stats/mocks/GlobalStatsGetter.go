@@ -0,0 +1,58 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "analiticsURLShortener/internal/storage"
+)
+
+// GlobalStatsGetter is an autogenerated mock type for the GlobalStatsGetter type
+type GlobalStatsGetter struct {
+	mock.Mock
+}
+
+// GetGlobalStats provides a mock function with given fields: ctx, q, limit
+func (_m *GlobalStatsGetter) GetGlobalStats(ctx context.Context, q storage.AnalyticsQuery, limit int) (storage.GlobalStats, error) {
+	ret := _m.Called(ctx, q, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGlobalStats")
+	}
+
+	var r0 storage.GlobalStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.AnalyticsQuery, int) (storage.GlobalStats, error)); ok {
+		return rf(ctx, q, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.AnalyticsQuery, int) storage.GlobalStats); ok {
+		r0 = rf(ctx, q, limit)
+	} else {
+		r0 = ret.Get(0).(storage.GlobalStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.AnalyticsQuery, int) error); ok {
+		r1 = rf(ctx, q, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewGlobalStatsGetter creates a new instance of GlobalStatsGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewGlobalStatsGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *GlobalStatsGetter {
+	mock := &GlobalStatsGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
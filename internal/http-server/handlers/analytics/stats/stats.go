@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/analytics"
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 100
+)
+
+type Response struct {
+	response.Response
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Granularity string    `json:"granularity"`
+	Timezone    string    `json:"timezone"`
+	TotalLinks  int64     `json:"total_links"`
+	TotalClicks int64     `json:"total_clicks"`
+	Series      []Point   `json:"series"`
+	TopLinks    []Link    `json:"top_links"`
+}
+
+type Point struct {
+	Time   time.Time `json:"time"`
+	Clicks int64     `json:"clicks"`
+}
+
+type Link struct {
+	Domain string `json:"domain,omitempty"`
+	Alias  string `json:"alias"`
+	URL    string `json:"url"`
+	Clicks int64  `json:"clicks"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=GlobalStatsGetter
+type GlobalStatsGetter interface {
+	GetGlobalStats(ctx context.Context, q storage.AnalyticsQuery, limit int) (storage.GlobalStats, error)
+}
+
+// New returns the top links and click volume across all links of the domain
+// of the request, through the cache shared with the dashboard.
+func New(log *slog.Logger, cache *Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.analytics.stats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		q, err := analytics.ParseQuery(r, time.Now())
+		if err != nil {
+			log.Info("invalid stats query", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		limit, err := parseLimit(r.URL.Query().Get("limit"))
+		if err != nil {
+			log.Info("invalid limit", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		q, stats, err := cache.Get(r, q, limit)
+		if err != nil {
+			log.Error("failed to get global stats", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		render.JSON(w, r, newResponse(q, stats))
+	}
+}
+
+func parseLimit(s string) (int, error) {
+	if s == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+	}
+
+	return limit, nil
+}
+
+func newResponse(q storage.AnalyticsQuery, stats storage.GlobalStats) Response {
+	series := make([]Point, 0, len(stats.Series))
+	for _, p := range stats.Series {
+		series = append(series, Point{Time: p.Time.In(q.Location), Clicks: p.Clicks})
+	}
+
+	links := make([]Link, 0, len(stats.TopLinks))
+	for _, l := range stats.TopLinks {
+		links = append(links, Link{Domain: l.Domain, Alias: l.Alias, URL: l.URL, Clicks: l.Clicks})
+	}
+
+	return Response{
+		Response:    response.OK(),
+		From:        q.From.In(q.Location),
+		To:          q.To.In(q.Location),
+		Granularity: string(q.Granularity),
+		Timezone:    q.Location.String(),
+		TotalLinks:  stats.TotalLinks,
+		TotalClicks: stats.TotalClicks,
+		Series:      series,
+		TopLinks:    links,
+	}
+}
@@ -0,0 +1,167 @@
+package stats
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/analytics/stats/mocks"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedLimit int
+		mockStats     storage.GlobalStats
+		mockError     error
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:          "Success",
+			query:         "?from=2025-08-11&to=2025-08-12&limit=2",
+			expectedLimit: 2,
+			mockStats: storage.GlobalStats{
+				TotalLinks:  5,
+				TotalClicks: 12,
+				Series: []storage.Point{
+					{Time: time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC), Clicks: 12},
+					{Time: time.Date(2025, 8, 12, 0, 0, 0, 0, time.UTC)},
+				},
+				TopLinks: []storage.LinkClicks{
+					{Alias: "promo", URL: "https://example.com/promo", Clicks: 9},
+					{Alias: "blog", URL: "https://example.com/blog", Clicks: 3},
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{
+				"status": "OK",
+				"from": "2025-08-11T00:00:00Z",
+				"to": "2025-08-13T00:00:00Z",
+				"granularity": "day",
+				"timezone": "UTC",
+				"total_links": 5,
+				"total_clicks": 12,
+				"series": [
+					{"time": "2025-08-11T00:00:00Z", "clicks": 12},
+					{"time": "2025-08-12T00:00:00Z", "clicks": 0}
+				],
+				"top_links": [
+					{"alias": "promo", "url": "https://example.com/promo", "clicks": 9},
+					{"alias": "blog", "url": "https://example.com/blog", "clicks": 3}
+				]
+			}`,
+		},
+		{
+			name:          "Default limit",
+			query:         "?from=2025-08-11&to=2025-08-12",
+			expectedLimit: defaultLimit,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:         "Invalid limit",
+			query:        "?limit=1000",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status": "Error", "error": "limit must be between 1 and 100"}`,
+		},
+		{
+			name:         "Invalid range",
+			query:        "?from=2025-08-12&to=2025-08-11T00:00:00Z",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status": "Error", "error": "from must be before to"}`,
+		},
+		{
+			name:          "Storage error",
+			query:         "?from=2025-08-11&to=2025-08-12",
+			expectedLimit: defaultLimit,
+			mockError:     errors.New("db is down"),
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  `{"status": "Error", "error": "internal error"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			getter := mocks.NewGlobalStatsGetter(t)
+			if tc.expectedLimit > 0 {
+				getter.On("GetGlobalStats", mock.Anything, mock.AnythingOfType("storage.AnalyticsQuery"), tc.expectedLimit).
+					Return(tc.mockStats, tc.mockError).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/stats"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			New(slog.Default(), NewCache(getter, 0)).ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedCode, rr.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestNew_Cache(t *testing.T) {
+	getter := mocks.NewGlobalStatsGetter(t)
+	getter.On("GetGlobalStats", mock.Anything, mock.AnythingOfType("storage.AnalyticsQuery"), defaultLimit).
+		Return(storage.GlobalStats{TotalLinks: 1}, nil).Once()
+	getter.On("GetGlobalStats", mock.Anything, mock.AnythingOfType("storage.AnalyticsQuery"), 5).
+		Return(storage.GlobalStats{TotalLinks: 2}, nil).Once()
+
+	handler := New(slog.Default(), NewCache(getter, time.Minute))
+
+	get := func(query string) int64 {
+		req := httptest.NewRequest(http.MethodGet, "/stats"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp.TotalLinks
+	}
+
+	assert.Equal(t, int64(1), get("?granularity=day&tz=UTC"))
+	// Same parameters in a different order hit the cache
+	assert.Equal(t, int64(1), get("?tz=UTC&granularity=day"))
+	assert.Equal(t, int64(2), get("?granularity=day&tz=UTC&limit=5"))
+}
+
+func TestNew_CachePerDomain(t *testing.T) {
+	getter := mocks.NewGlobalStatsGetter(t)
+	getter.On("GetGlobalStats", mock.Anything, mock.AnythingOfType("storage.AnalyticsQuery"), defaultLimit).
+		Return(func(ctx context.Context, _ storage.AnalyticsQuery, _ int) (storage.GlobalStats, error) {
+			if storage.DomainFromContext(ctx) == "brand.example" {
+				return storage.GlobalStats{TotalLinks: 2}, nil
+			}
+			return storage.GlobalStats{TotalLinks: 1}, nil
+		}).Twice()
+
+	handler := New(slog.Default(), NewCache(getter, time.Minute))
+
+	get := func(domain string) int64 {
+		req := httptest.NewRequest(http.MethodGet, "/stats?granularity=day&tz=UTC", nil)
+		req = req.WithContext(storage.WithDomain(req.Context(), domain))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp.TotalLinks
+	}
+
+	assert.Equal(t, int64(1), get(""))
+	assert.Equal(t, int64(2), get("brand.example"))
+	assert.Equal(t, int64(1), get(""))
+	assert.Equal(t, int64(2), get("brand.example"))
+}
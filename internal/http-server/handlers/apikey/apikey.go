@@ -0,0 +1,188 @@
+package apikey
+
+import (
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/apikey"
+	"analiticsURLShortener/internal/lib/hostname"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	// Domain is the registered custom domain the key creates links on;
+	// empty for the default domain.
+	Domain string `json:"domain,omitempty" validate:"omitempty,fqdn"`
+}
+
+type Key struct {
+	Prefix    string    `json:"prefix"`
+	Domain    string    `json:"domain,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Response struct {
+	response.Response
+	// Key is the full API key. It is only returned when the key is created.
+	Key    string `json:"key,omitempty"`
+	APIKey *Key   `json:"api_key,omitempty"`
+}
+
+type ListResponse struct {
+	response.Response
+	APIKeys []Key `json:"api_keys"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=KeySaver
+type KeySaver interface {
+	SaveAPIKey(ctx context.Context, prefix string, secretHash []byte, host string) (storage.APIKey, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=KeyLister
+type KeyLister interface {
+	ListAPIKeys(ctx context.Context) ([]storage.APIKey, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=KeyDeleter
+type KeyDeleter interface {
+	DeleteAPIKey(ctx context.Context, prefix string) error
+}
+
+// NewSave issues an API key for a domain. Links created with the key are
+// always created on that domain.
+func NewSave(log *slog.Logger, saver KeySaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.NewSave"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+
+			return
+		}
+
+		key, prefix, hash, err := apikey.Generate()
+		if err != nil {
+			log.Error("failed to generate api key", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to add api key"))
+
+			return
+		}
+
+		k, err := saver.SaveAPIKey(r.Context(), prefix, hash, hostname.Normalize(req.Domain))
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			log.Info("domain not found", slog.String("domain", req.Domain))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("domain not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to add api key", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to add api key"))
+
+			return
+		}
+
+		log.Info("api key added", slog.String("prefix", k.Prefix), slog.String("domain", k.Domain))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Key:      key,
+			APIKey:   &Key{Prefix: k.Prefix, Domain: k.Domain, CreatedAt: k.CreatedAt},
+		})
+	}
+}
+
+func NewList(log *slog.Logger, lister KeyLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		keys, err := lister.ListAPIKeys(r.Context())
+		if err != nil {
+			log.Error("failed to list api keys", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to list api keys"))
+
+			return
+		}
+
+		resp := ListResponse{Response: response.OK(), APIKeys: make([]Key, 0, len(keys))}
+		for _, k := range keys {
+			resp.APIKeys = append(resp.APIKeys, Key{Prefix: k.Prefix, Domain: k.Domain, CreatedAt: k.CreatedAt})
+		}
+
+		render.JSON(w, r, resp)
+	}
+}
+
+// NewDelete revokes the API key with the prefix in the URL.
+func NewDelete(log *slog.Logger, deleter KeyDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.NewDelete"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		prefix := chi.URLParam(r, "prefix")
+
+		err := deleter.DeleteAPIKey(r.Context(), prefix)
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			log.Info("api key not found", slog.String("prefix", prefix))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("api key not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete api key", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to delete api key"))
+
+			return
+		}
+
+		log.Info("api key deleted", slog.String("prefix", prefix))
+
+		render.JSON(w, r, response.OK())
+	}
+}
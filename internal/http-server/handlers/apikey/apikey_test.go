@@ -0,0 +1,202 @@
+package apikey
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/apikey/mocks"
+	"analiticsURLShortener/internal/lib/apikey"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var createdAt = time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC)
+
+func TestNewSave(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		save         bool
+		host         string
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Custom domain",
+			save:         true,
+			requestBody:  `{"domain": "Brand.Example"}`,
+			host:         "brand.example",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Default domain",
+			save:         true,
+			requestBody:  `{}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Unknown domain",
+			save:         true,
+			requestBody:  `{"domain": "brand.example"}`,
+			host:         "brand.example",
+			mockError:    storage.ErrDomainNotFound,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"domain not found"}`,
+		},
+		{
+			name:         "Invalid domain",
+			requestBody:  `{"domain": "https://brand.example/"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"field Domain is not valid"}`,
+		},
+		{
+			name:         "Storage error",
+			requestBody:  `{}`,
+			save:         true,
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status":"Error","error":"failed to add api key"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saver := mocks.NewKeySaver(t)
+
+			var savedPrefix string
+			var savedHash []byte
+			if tt.save {
+				saver.On("SaveAPIKey", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8"), tt.host).
+					Run(func(args mock.Arguments) {
+						savedPrefix = args.String(1)
+						savedHash = args.Get(2).([]byte)
+					}).
+					Return(func(_ context.Context, prefix string, hash []byte, host string) (storage.APIKey, error) {
+						return storage.APIKey{Prefix: prefix, SecretHash: hash, Domain: host, CreatedAt: createdAt}, tt.mockError
+					}).Once()
+			}
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(tt.requestBody))
+			NewSave(slog.Default(), saver).ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+				return
+			}
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			require.NotNil(t, resp.APIKey)
+			assert.Equal(t, savedPrefix, resp.APIKey.Prefix)
+			assert.Equal(t, tt.host, resp.APIKey.Domain)
+
+			prefix, secret, ok := apikey.Parse(resp.Key)
+			require.True(t, ok)
+			assert.Equal(t, savedPrefix, prefix)
+			assert.True(t, apikey.Matches(secret, savedHash))
+		})
+	}
+}
+
+func TestNewList(t *testing.T) {
+	tests := []struct {
+		name         string
+		keys         []storage.APIKey
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "Success",
+			keys: []storage.APIKey{
+				{Prefix: "a1b2c3", Domain: "brand.example", CreatedAt: createdAt},
+				{Prefix: "d4e5f6", CreatedAt: createdAt},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","api_keys":[
+				{"prefix":"a1b2c3","domain":"brand.example","created_at":"2025-08-11T10:00:00Z"},
+				{"prefix":"d4e5f6","created_at":"2025-08-11T10:00:00Z"}]}`,
+		},
+		{
+			name:         "Empty",
+			keys:         []storage.APIKey{},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","api_keys":[]}`,
+		},
+		{
+			name:         "Storage error",
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status":"Error","error":"failed to list api keys"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lister := mocks.NewKeyLister(t)
+			lister.On("ListAPIKeys", mock.Anything).Return(tt.keys, tt.mockError).Once()
+
+			recorder := httptest.NewRecorder()
+			NewList(slog.Default(), lister).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api-keys", nil))
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestNewDelete(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Success",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK"}`,
+		},
+		{
+			name:         "Not found",
+			mockError:    storage.ErrAPIKeyNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"status":"Error","error":"api key not found"}`,
+		},
+		{
+			name:         "Storage error",
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status":"Error","error":"failed to delete api key"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleter := mocks.NewKeyDeleter(t)
+			deleter.On("DeleteAPIKey", mock.Anything, "a1b2c3").Return(tt.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Delete("/api-keys/{prefix}", NewDelete(slog.Default(), deleter))
+
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/api-keys/a1b2c3", nil))
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// KeyDeleter is an autogenerated mock type for the KeyDeleter type
+type KeyDeleter struct {
+	mock.Mock
+}
+
+// DeleteAPIKey provides a mock function with given fields: ctx, prefix
+func (_m *KeyDeleter) DeleteAPIKey(ctx context.Context, prefix string) error {
+	ret := _m.Called(ctx, prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, prefix)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewKeyDeleter creates a new instance of KeyDeleter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewKeyDeleter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *KeyDeleter {
+	mock := &KeyDeleter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,57 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "analiticsURLShortener/internal/storage"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// KeySaver is an autogenerated mock type for the KeySaver type
+type KeySaver struct {
+	mock.Mock
+}
+
+// SaveAPIKey provides a mock function with given fields: ctx, prefix, secretHash, host
+func (_m *KeySaver) SaveAPIKey(ctx context.Context, prefix string, secretHash []byte, host string) (storage.APIKey, error) {
+	ret := _m.Called(ctx, prefix, secretHash, host)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveAPIKey")
+	}
+
+	var r0 storage.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, string) (storage.APIKey, error)); ok {
+		return rf(ctx, prefix, secretHash, host)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, string) storage.APIKey); ok {
+		r0 = rf(ctx, prefix, secretHash, host)
+	} else {
+		r0 = ret.Get(0).(storage.APIKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, string) error); ok {
+		r1 = rf(ctx, prefix, secretHash, host)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewKeySaver creates a new instance of KeySaver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewKeySaver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *KeySaver {
+	mock := &KeySaver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,220 @@
+package badge
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/chart"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/lib/timeseries"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	maxLabel       = 32
+	maxDays        = 365
+	sparklineWidth = 100
+	sparklineHigh  = 20
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=ClickCounter
+type ClickCounter interface {
+	GetLink(ctx context.Context, alias string) (storage.Link, error)
+	CountClicks(ctx context.Context, alias string) (int64, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=SeriesGetter
+type SeriesGetter interface {
+	GetLink(ctx context.Context, alias string) (storage.Link, error)
+	GetClickSeries(ctx context.Context, alias string, q storage.AnalyticsQuery) ([]storage.Point, error)
+}
+
+// New returns a shields-style SVG badge with the total clicks of a link, for
+// /badge/{alias}.svg. The label parameter replaces "clicks". Links that aren't
+// embeddable are reported as not found.
+func New(log *slog.Logger, counter ClickCounter, cfg config.Badges) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.badge.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		alias, ok := embeddableLink(w, r, log, counter.GetLink)
+		if !ok {
+			return
+		}
+
+		label := r.URL.Query().Get("label")
+		if label == "" {
+			label = "clicks"
+		}
+		if len([]rune(label)) > maxLabel {
+			log.Info("label is too long")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("label must be at most %d characters", maxLabel)))
+			return
+		}
+
+		clicks, err := counter.CountClicks(r.Context(), alias)
+		if err != nil {
+			log.Error("failed to count clicks", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := chart.Badge(&buf, label, Compact(clicks), chart.BadgeColor); err != nil {
+			log.Error("failed to draw badge", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		writeSVG(w, log, &buf, cfg.MaxAge)
+	}
+}
+
+// NewSparkline returns the daily clicks of a link over the last days (UTC) as
+// a small SVG line, for /sparkline/{alias}.svg. Links that aren't
+// embeddable are reported as not found.
+func NewSparkline(log *slog.Logger, getter SeriesGetter, cfg config.Badges) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.badge.NewSparkline"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		days := cfg.SparklineDays
+		if v := r.URL.Query().Get("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxDays {
+				log.Info("invalid days", slog.String("days", v))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error(fmt.Sprintf("days must be between 1 and %d", maxDays)))
+				return
+			}
+			days = n
+		}
+
+		alias, ok := embeddableLink(w, r, log, getter.GetLink)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		q := storage.AnalyticsQuery{
+			From:        timeseries.Truncate(now, timeseries.Day, time.UTC).AddDate(0, 0, 1-days),
+			To:          now,
+			Granularity: timeseries.Day,
+			Location:    time.UTC,
+		}
+
+		series, err := getter.GetClickSeries(r.Context(), alias, q)
+		if err != nil {
+			log.Error("failed to get click series", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		values := make([]int64, 0, len(series))
+		for _, p := range series {
+			values = append(values, p.Clicks)
+		}
+
+		var buf bytes.Buffer
+		if err := chart.Sparkline(&buf, values, sparklineWidth, sparklineHigh); err != nil {
+			log.Error("failed to draw sparkline", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		writeSVG(w, log, &buf, cfg.MaxAge)
+	}
+}
+
+// embeddableLink returns the alias of the request if it asks for an SVG image
+// of an embeddable link, and responds with an error otherwise.
+func embeddableLink(w http.ResponseWriter, r *http.Request, log *slog.Logger,
+	getLink func(context.Context, string) (storage.Link, error),
+) (string, bool) {
+	alias := chi.URLParam(r, "short_url")
+	if ext, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); alias == "" || ext != "svg" {
+		log.Info("not an svg request", slog.String("alias", alias))
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("not found"))
+		return "", false
+	}
+
+	link, err := getLink(r.Context(), alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		log.Info("url not found", "alias", alias)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("not found"))
+		return "", false
+	}
+	if err != nil {
+		log.Error("failed to get link", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("internal error"))
+		return "", false
+	}
+
+	// private links look the same as missing ones
+	if !link.Options.Embeddable {
+		log.Info("analytics are private", "alias", alias)
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("not found"))
+		return "", false
+	}
+
+	return alias, true
+}
+
+func writeSVG(w http.ResponseWriter, log *slog.Logger, buf *bytes.Buffer, maxAge time.Duration) {
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Info("failed to write image", sl.Err(err))
+	}
+}
+
+// Compact formats n with at most one decimal and a k, M or B suffix, e.g.
+// 1290 as "1.2k", like shields.io badges. Values are rounded down so that a
+// badge never shows more clicks than there were.
+func Compact(n int64) string {
+	for _, unit := range []struct {
+		size   float64
+		suffix string
+	}{{1e9, "B"}, {1e6, "M"}, {1e3, "k"}} {
+		if v := float64(n); v >= unit.size {
+			s := strconv.FormatFloat(math.Floor(v/unit.size*10)/10, 'f', 1, 64)
+			s = strings.TrimSuffix(s, ".0")
+			return s + unit.suffix
+		}
+	}
+
+	return strconv.FormatInt(n, 10)
+}
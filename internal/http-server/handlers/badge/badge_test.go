@@ -0,0 +1,147 @@
+package badge
+
+import (
+	"analiticsURLShortener/internal/config"
+	"analiticsURLShortener/internal/http-server/handlers/badge/mocks"
+	"analiticsURLShortener/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var cfg = config.Badges{MaxAge: 5 * time.Minute, SparklineDays: 30}
+
+func serve(pattern string, h http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Use(middleware.URLFormat)
+	router.Get(pattern, h)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	return recorder
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		link         storage.Link
+		linkError    error
+		clicks       int64
+		clicksError  error
+		expectedCode int
+		contains     string
+	}{
+		{
+			name:         "Success",
+			target:       "/badge/promo.svg",
+			link:         storage.Link{Options: storage.LinkOptions{Embeddable: true}},
+			clicks:       1290,
+			expectedCode: http.StatusOK,
+			contains:     `aria-label="clicks: 1.2k"`,
+		},
+		{
+			name:         "Custom label",
+			target:       "/badge/promo.svg?label=visits",
+			link:         storage.Link{Options: storage.LinkOptions{Embeddable: true}},
+			clicks:       7,
+			expectedCode: http.StatusOK,
+			contains:     `aria-label="visits: 7"`,
+		},
+		{
+			name:         "Private analytics",
+			target:       "/badge/promo.svg",
+			expectedCode: http.StatusNotFound,
+			contains:     `{"status":"Error","error":"not found"}`,
+		},
+		{
+			name:         "Not found",
+			target:       "/badge/promo.svg",
+			linkError:    storage.ErrURLNotFound,
+			expectedCode: http.StatusNotFound,
+			contains:     `{"status":"Error","error":"not found"}`,
+		},
+		{
+			name:         "Count error",
+			target:       "/badge/promo.svg",
+			link:         storage.Link{Options: storage.LinkOptions{Embeddable: true}},
+			clicksError:  errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			contains:     `{"status":"Error","error":"internal error"}`,
+		},
+		{
+			name:         "Not svg",
+			target:       "/badge/promo.png",
+			expectedCode: http.StatusNotFound,
+			contains:     `{"status":"Error","error":"not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := mocks.NewClickCounter(t)
+			if tt.name != "Not svg" {
+				counter.On("GetLink", mock.Anything, "promo").Return(tt.link, tt.linkError).Once()
+			}
+			if tt.link.Options.Embeddable {
+				counter.On("CountClicks", mock.Anything, "promo").Return(tt.clicks, tt.clicksError).Once()
+			}
+
+			recorder := serve("/badge/{short_url}", New(slog.Default(), counter, cfg), tt.target)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tt.contains)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, "image/svg+xml", recorder.Header().Get("Content-Type"))
+				assert.Equal(t, "public, max-age=300", recorder.Header().Get("Cache-Control"))
+			} else {
+				assert.Empty(t, recorder.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestNewSparkline(t *testing.T) {
+	getter := mocks.NewSeriesGetter(t)
+	getter.On("GetLink", mock.Anything, "promo").
+		Return(storage.Link{Options: storage.LinkOptions{Embeddable: true}}, nil).Once()
+	getter.On("GetClickSeries", mock.Anything, "promo", mock.MatchedBy(func(q storage.AnalyticsQuery) bool {
+		return q.To.Sub(q.From) > 6*24*time.Hour && q.To.Sub(q.From) <= 7*24*time.Hour
+	})).Return([]storage.Point{{Clicks: 1}, {Clicks: 3}}, nil).Once()
+
+	recorder := serve("/sparkline/{short_url}", NewSparkline(slog.Default(), getter, cfg), "/sparkline/promo.svg?days=7")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<polyline")
+	assert.Equal(t, "public, max-age=300", recorder.Header().Get("Cache-Control"))
+}
+
+func TestNewSparkline_InvalidDays(t *testing.T) {
+	recorder := serve("/sparkline/{short_url}", NewSparkline(slog.Default(), mocks.NewSeriesGetter(t), cfg), "/sparkline/promo.svg?days=0")
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"status":"Error","error":"days must be between 1 and 365"}`, recorder.Body.String())
+}
+
+func TestCompact(t *testing.T) {
+	for n, expected := range map[int64]string{
+		0:          "0",
+		999:        "999",
+		1000:       "1k",
+		1290:       "1.2k",
+		999999:     "999.9k",
+		2500000:    "2.5M",
+		3000000000: "3B",
+	} {
+		assert.Equal(t, expected, Compact(n))
+	}
+}
@@ -0,0 +1,87 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "analiticsURLShortener/internal/storage"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SeriesGetter is an autogenerated mock type for the SeriesGetter type
+type SeriesGetter struct {
+	mock.Mock
+}
+
+// GetClickSeries provides a mock function with given fields: ctx, alias, q
+func (_m *SeriesGetter) GetClickSeries(ctx context.Context, alias string, q storage.AnalyticsQuery) ([]storage.Point, error) {
+	ret := _m.Called(ctx, alias, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetClickSeries")
+	}
+
+	var r0 []storage.Point
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.AnalyticsQuery) ([]storage.Point, error)); ok {
+		return rf(ctx, alias, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.AnalyticsQuery) []storage.Point); ok {
+		r0 = rf(ctx, alias, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Point)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, storage.AnalyticsQuery) error); ok {
+		r1 = rf(ctx, alias, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLink provides a mock function with given fields: ctx, alias
+func (_m *SeriesGetter) GetLink(ctx context.Context, alias string) (storage.Link, error) {
+	ret := _m.Called(ctx, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLink")
+	}
+
+	var r0 storage.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Link, error)); ok {
+		return rf(ctx, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Link); ok {
+		r0 = rf(ctx, alias)
+	} else {
+		r0 = ret.Get(0).(storage.Link)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewSeriesGetter creates a new instance of SeriesGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSeriesGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SeriesGetter {
+	mock := &SeriesGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,342 @@
+package dashboard
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/analytics"
+	"analiticsURLShortener/internal/http-server/handlers/analytics/stats"
+	"analiticsURLShortener/internal/lib/chart"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/lib/timeseries"
+	"analiticsURLShortener/internal/lib/useragent"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	seriesWidth  = 760
+	seriesHeight = 200
+	barsWidth    = 360
+	topLimit     = 10
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var (
+	linkPage     = parse("link.html")
+	overviewPage = parse("overview.html")
+	errorPage    = parse("error.html")
+)
+
+func parse(name string) *template.Template {
+	return template.Must(template.ParseFS(templateFS, "templates/layout.html", "templates/"+name))
+}
+
+type query struct {
+	From          string
+	To            string
+	Granularity   string
+	Timezone      string
+	Granularities []timeseries.Granularity
+}
+
+type breakdown struct {
+	Title string
+	Chart template.HTML
+}
+
+type linkData struct {
+	Title          string
+	Alias          string
+	Domain         string
+	Query          query
+	TotalClicks    int64
+	UniqueVisitors int64
+	Series         template.HTML
+	Breakdowns     []breakdown
+}
+
+type topLink struct {
+	Domain string
+	Alias  string
+	URL    string
+	Clicks int64
+	Href   string
+}
+
+type overviewData struct {
+	Title       string
+	Query       query
+	TotalLinks  int64
+	TotalClicks int64
+	Series      template.HTML
+	TopLinks    []topLink
+}
+
+type errorData struct {
+	Title   string
+	Message string
+}
+
+// New renders the analytics of a link as an HTML page with SVG charts. It
+// takes the same query parameters as the analytics API.
+func New(log *slog.Logger, analyticsGetter analytics.URLAnalyticsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.dashboard.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "short_url")
+
+		q, err := analytics.ParseQuery(r, time.Now())
+		if err != nil {
+			log.Info("invalid dashboard query", sl.Err(err))
+			renderError(w, log, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		data, err := analyticsGetter.GetAnalytics(r.Context(), alias, q)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+			renderError(w, log, http.StatusNotFound, "There is no link "+alias+".")
+			return
+		}
+		if err != nil {
+			log.Error("failed to get analytics", sl.Err(err))
+			renderError(w, log, http.StatusInternalServerError, "Analytics are unavailable, try again later.")
+			return
+		}
+
+		page := linkData{
+			Title:          "Analytics of " + alias,
+			Alias:          alias,
+			Domain:         storage.DomainFromContext(r.Context()),
+			Query:          newQuery(r, q),
+			TotalClicks:    data.TotalClicks,
+			UniqueVisitors: data.UniqueVisitors,
+		}
+
+		if page.Series, err = seriesChart(data.Series, q); err == nil {
+			page.Breakdowns, err = breakdowns(data)
+		}
+		if err != nil {
+			log.Error("failed to draw charts", sl.Err(err))
+			renderError(w, log, http.StatusInternalServerError, "Analytics are unavailable, try again later.")
+			return
+		}
+
+		renderPage(w, log, linkPage, "link.html", page)
+	}
+}
+
+// NewOverview renders the clicks across all links and the top links, through
+// the cache shared with /stats.
+func NewOverview(log *slog.Logger, cache *stats.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.dashboard.NewOverview"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		q, err := analytics.ParseQuery(r, time.Now())
+		if err != nil {
+			log.Info("invalid dashboard query", sl.Err(err))
+			renderError(w, log, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		q, data, err := cache.Get(r, q, topLimit)
+		if err != nil {
+			log.Error("failed to get global stats", sl.Err(err))
+			renderError(w, log, http.StatusInternalServerError, "Analytics are unavailable, try again later.")
+			return
+		}
+
+		page := overviewData{
+			Title:       "All links",
+			Query:       newQuery(r, q),
+			TotalLinks:  data.TotalLinks,
+			TotalClicks: data.TotalClicks,
+			TopLinks:    make([]topLink, 0, len(data.TopLinks)),
+		}
+		for _, l := range data.TopLinks {
+			page.TopLinks = append(page.TopLinks, topLink{
+				Domain: l.Domain,
+				Alias:  l.Alias,
+				URL:    l.URL,
+				Clicks: l.Clicks,
+				Href:   linkHref(l.Domain, l.Alias),
+			})
+		}
+
+		if page.Series, err = seriesChart(data.Series, q); err != nil {
+			log.Error("failed to draw charts", sl.Err(err))
+			renderError(w, log, http.StatusInternalServerError, "Analytics are unavailable, try again later.")
+			return
+		}
+
+		renderPage(w, log, overviewPage, "overview.html", page)
+	}
+}
+
+// linkHref points to the dashboard of a link, on its own domain if it has
+// one, since aliases are resolved by the Host header.
+func linkHref(domain, alias string) string {
+	path := "/dashboard/" + url.PathEscape(alias)
+	if domain != "" {
+		return "//" + domain + path
+	}
+	return path
+}
+
+// newQuery fills the query form with the parameters of r, falling back to
+// the values in effect.
+func newQuery(r *http.Request, q storage.AnalyticsQuery) query {
+	params := r.URL.Query()
+
+	res := query{
+		From:          params.Get("from"),
+		To:            params.Get("to"),
+		Granularity:   string(q.Granularity),
+		Timezone:      q.Location.String(),
+		Granularities: []timeseries.Granularity{timeseries.Hour, timeseries.Day, timeseries.Week, timeseries.Month},
+	}
+	if res.From == "" {
+		res.From = q.From.In(q.Location).Format(time.DateOnly)
+	}
+	if res.To == "" {
+		// to is exclusive, the form shows the last included day
+		res.To = q.To.Add(-time.Nanosecond).In(q.Location).Format(time.DateOnly)
+	}
+
+	return res
+}
+
+func seriesChart(series []storage.Point, q storage.AnalyticsQuery) (template.HTML, error) {
+	layout := "Jan 2"
+	switch q.Granularity {
+	case timeseries.Hour:
+		layout = "Jan 2 15:04"
+	case timeseries.Month:
+		layout = "Jan 2006"
+	}
+
+	points := make([]chart.Point, 0, len(series))
+	for _, p := range series {
+		points = append(points, chart.Point{Label: p.Time.In(q.Location).Format(layout), Value: p.Clicks})
+	}
+
+	var buf bytes.Buffer
+	if err := chart.Series(&buf, points, seriesWidth, seriesHeight); err != nil {
+		return "", err
+	}
+
+	// chart escapes everything it draws
+	return template.HTML(buf.String()), nil
+}
+
+func breakdowns(data storage.AnalyticsData) ([]breakdown, error) {
+	devices := make(map[string]int64)
+	for ua, clicks := range data.UserAgents {
+		devices[useragent.Device(ua)] += clicks
+	}
+
+	sections := []struct {
+		title string
+		bars  []chart.Bar
+	}{
+		{title: "Devices", bars: sortedBars(devices)},
+		{title: "User agents", bars: sortedBars(data.UserAgents)},
+		{title: "Referrers", bars: countBars(data.Referrers)},
+		{title: "Sources", bars: countBars(data.Sources)},
+		{title: "Countries", bars: countBars(data.Countries)},
+		{title: "Cities", bars: countBars(data.Cities)},
+	}
+
+	res := make([]breakdown, 0, len(sections))
+	for _, s := range sections {
+		var buf bytes.Buffer
+		if err := chart.Bars(&buf, s.bars, barsWidth); err != nil {
+			return nil, err
+		}
+		res = append(res, breakdown{Title: s.title, Chart: template.HTML(buf.String())})
+	}
+
+	return res, nil
+}
+
+// sortedBars returns the top values of counts by clicks, then by label.
+func sortedBars(counts map[string]int64) []chart.Bar {
+	bars := make([]chart.Bar, 0, len(counts))
+	for label, clicks := range counts {
+		if strings.TrimSpace(label) == "" {
+			label = "(none)"
+		}
+		bars = append(bars, chart.Bar{Label: label, Value: clicks})
+	}
+
+	sort.Slice(bars, func(i, j int) bool {
+		if bars[i].Value != bars[j].Value {
+			return bars[i].Value > bars[j].Value
+		}
+		return bars[i].Label < bars[j].Label
+	})
+
+	return bars[:min(len(bars), topLimit)]
+}
+
+func countBars(counts []storage.Count) []chart.Bar {
+	bars := make([]chart.Bar, 0, len(counts))
+	for _, c := range counts {
+		bars = append(bars, chart.Bar{Label: c.Value, Value: c.Clicks})
+	}
+
+	return bars
+}
+
+func renderPage(w http.ResponseWriter, log *slog.Logger, page *template.Template, name string, data any) {
+	var buf bytes.Buffer
+	if err := page.ExecuteTemplate(&buf, name, data); err != nil {
+		log.Error("failed to render page", sl.Err(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Info("failed to write page", sl.Err(err))
+	}
+}
+
+func renderError(w http.ResponseWriter, log *slog.Logger, status int, message string) {
+	var buf bytes.Buffer
+	err := errorPage.ExecuteTemplate(&buf, "error.html", errorData{Title: http.StatusText(status), Message: message})
+	if err != nil {
+		log.Error("failed to render error page", sl.Err(err))
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
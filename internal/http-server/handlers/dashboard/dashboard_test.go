@@ -0,0 +1,164 @@
+package dashboard
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/analytics/mocks"
+	"analiticsURLShortener/internal/http-server/handlers/analytics/stats"
+	statsMocks "analiticsURLShortener/internal/http-server/handlers/analytics/stats/mocks"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		alias        string
+		query        string
+		analytics    storage.AnalyticsData
+		mockError    error
+		expectedCode int
+		contains     []string
+	}{
+		{
+			name:  "Success",
+			alias: "promo",
+			query: "?from=2025-08-01&to=2025-08-02",
+			analytics: storage.AnalyticsData{
+				TotalClicks:    12,
+				UniqueVisitors: 7,
+				Series: []storage.Point{
+					{Time: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Clicks: 5},
+					{Time: time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC), Clicks: 7},
+				},
+				UserAgents: map[string]int64{
+					"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148": 9,
+					"<script>alert(1)</script>": 3,
+				},
+				Referrers: []storage.Count{{Value: "google.com", Clicks: 12}},
+			},
+			expectedCode: http.StatusOK,
+			contains: []string{
+				"<h1>promo</h1>",
+				`<div class="total">12</div>`,
+				`<div class="total">7</div>`,
+				"<svg",
+				"Aug 1",
+				"Devices",
+				"mobile",
+				"google.com",
+				"&lt;script&gt;",
+				`value="2025-08-01"`,
+				`value="2025-08-02"`,
+			},
+		},
+		{
+			name:         "Not found",
+			alias:        "missing",
+			mockError:    storage.ErrURLNotFound,
+			expectedCode: http.StatusNotFound,
+			contains:     []string{"There is no link missing."},
+		},
+		{
+			name:         "Internal error",
+			alias:        "promo",
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			contains:     []string{"Analytics are unavailable"},
+		},
+		{
+			name:         "Invalid query",
+			alias:        "promo",
+			query:        "?granularity=year",
+			expectedCode: http.StatusBadRequest,
+			contains:     []string{"invalid granularity &#34;year&#34;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := mocks.NewURLAnalyticsGetter(t)
+			if tt.expectedCode != http.StatusBadRequest {
+				getter.On("GetAnalytics", mock.Anything, tt.alias, mock.AnythingOfType("storage.AnalyticsQuery")).
+					Return(tt.analytics, tt.mockError).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/dashboard/"+tt.alias+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("short_url", tt.alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			recorder := httptest.NewRecorder()
+			New(slog.Default(), getter).ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+			for _, s := range tt.contains {
+				assert.Contains(t, recorder.Body.String(), s)
+			}
+			assert.NotContains(t, recorder.Body.String(), "<script>")
+		})
+	}
+}
+
+func TestNewOverview(t *testing.T) {
+	getter := statsMocks.NewGlobalStatsGetter(t)
+	getter.On("GetGlobalStats", mock.Anything, mock.AnythingOfType("storage.AnalyticsQuery"), topLimit).
+		Return(storage.GlobalStats{
+			TotalLinks:  2,
+			TotalClicks: 30,
+			Series:      []storage.Point{{Time: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Clicks: 30}},
+			TopLinks: []storage.LinkClicks{
+				{Alias: "promo", URL: "https://example.com", Clicks: 20},
+				{Domain: "brand.example", Alias: "a b", URL: "https://example.org", Clicks: 10},
+			},
+		}, nil).Once()
+
+	recorder := httptest.NewRecorder()
+	NewOverview(slog.Default(), stats.NewCache(getter, 0)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, `<div class="total">30</div>`)
+	assert.Contains(t, body, `href="/dashboard/promo"`)
+	assert.Contains(t, body, `href="//brand.example/dashboard/a%20b"`)
+	assert.Contains(t, body, "https://example.org")
+}
+
+func TestNewOverview_Error(t *testing.T) {
+	getter := statsMocks.NewGlobalStatsGetter(t)
+	getter.On("GetGlobalStats", mock.Anything, mock.Anything, topLimit).
+		Return(storage.GlobalStats{}, errors.New("db error")).Once()
+
+	recorder := httptest.NewRecorder()
+	NewOverview(slog.Default(), stats.NewCache(getter, 0)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "Analytics are unavailable")
+}
+
+func TestNewOverview_SharesStatsCache(t *testing.T) {
+	getter := statsMocks.NewGlobalStatsGetter(t)
+	getter.On("GetGlobalStats", mock.Anything, mock.AnythingOfType("storage.AnalyticsQuery"), topLimit).
+		Return(storage.GlobalStats{TotalLinks: 1, TotalClicks: 30}, nil).Once()
+
+	cache := stats.NewCache(getter, time.Minute)
+
+	recorder := httptest.NewRecorder()
+	stats.New(slog.Default(), cache).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stats?tz=UTC", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	NewOverview(slog.Default(), cache).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/dashboard?tz=UTC", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `<div class="total">30</div>`)
+}
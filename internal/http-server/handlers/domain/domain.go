@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/hostname"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	Host string `json:"host" validate:"required,fqdn"`
+}
+
+type Domain struct {
+	Host      string    `json:"host"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Response struct {
+	response.Response
+	Domain *Domain `json:"domain,omitempty"`
+}
+
+type ListResponse struct {
+	response.Response
+	Domains []Domain `json:"domains"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=DomainSaver
+type DomainSaver interface {
+	SaveDomain(ctx context.Context, host string) (storage.Domain, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=DomainLister
+type DomainLister interface {
+	ListDomains(ctx context.Context) ([]storage.Domain, error)
+}
+
+// NewSave registers a custom domain. Links can then be created on it, and
+// requests with its Host header resolve aliases among its links.
+func NewSave(log *slog.Logger, saver DomainSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.NewSave"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+
+			return
+		}
+
+		d, err := saver.SaveDomain(r.Context(), hostname.Normalize(req.Host))
+		if errors.Is(err, storage.ErrDomainExists) {
+			log.Info("domain already exists", slog.String("host", req.Host))
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("domain already exists"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to add domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to add domain"))
+
+			return
+		}
+
+		log.Info("domain added", slog.String("host", d.Host))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Domain:   &Domain{Host: d.Host, CreatedAt: d.CreatedAt},
+		})
+	}
+}
+
+func NewList(log *slog.Logger, lister DomainLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.NewList"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		domains, err := lister.ListDomains(r.Context())
+		if err != nil {
+			log.Error("failed to list domains", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to list domains"))
+
+			return
+		}
+
+		resp := ListResponse{Response: response.OK(), Domains: make([]Domain, 0, len(domains))}
+		for _, d := range domains {
+			resp.Domains = append(resp.Domains, Domain{Host: d.Host, CreatedAt: d.CreatedAt})
+		}
+
+		render.JSON(w, r, resp)
+	}
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/domain/mocks"
+	"analiticsURLShortener/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var createdAt = time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC)
+
+func TestNewSave(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		host         string
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Success",
+			requestBody:  `{"host": "Brand.Example"}`,
+			host:         "brand.example",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","domain":{"host":"brand.example","created_at":"2025-08-11T10:00:00Z"}}`,
+		},
+		{
+			name:         "Already exists",
+			requestBody:  `{"host": "brand.example"}`,
+			host:         "brand.example",
+			mockError:    storage.ErrDomainExists,
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"status":"Error","error":"domain already exists"}`,
+		},
+		{
+			name:         "Invalid host",
+			requestBody:  `{"host": "https://brand.example/"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"field Host is not valid"}`,
+		},
+		{
+			name:         "Missing host",
+			requestBody:  `{}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"field Host is a required field"}`,
+		},
+		{
+			name:         "Storage error",
+			requestBody:  `{"host": "brand.example"}`,
+			host:         "brand.example",
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status":"Error","error":"failed to add domain"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saver := mocks.NewDomainSaver(t)
+			if tt.host != "" {
+				saver.On("SaveDomain", mock.Anything, tt.host).
+					Return(storage.Domain{ID: 1, Host: tt.host, CreatedAt: createdAt}, tt.mockError).Once()
+			}
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader(tt.requestBody))
+			NewSave(slog.Default(), saver).ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestNewList(t *testing.T) {
+	tests := []struct {
+		name         string
+		domains      []storage.Domain
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Success",
+			domains:      []storage.Domain{{ID: 1, Host: "brand.example", CreatedAt: createdAt}},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","domains":[{"host":"brand.example","created_at":"2025-08-11T10:00:00Z"}]}`,
+		},
+		{
+			name:         "Empty",
+			domains:      []storage.Domain{},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","domains":[]}`,
+		},
+		{
+			name:         "Storage error",
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status":"Error","error":"failed to list domains"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lister := mocks.NewDomainLister(t)
+			lister.On("ListDomains", mock.Anything).Return(tt.domains, tt.mockError).Once()
+
+			recorder := httptest.NewRecorder()
+			NewList(slog.Default(), lister).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/domains", nil))
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "analiticsURLShortener/internal/storage"
+)
+
+// DomainSaver is an autogenerated mock type for the DomainSaver type
+type DomainSaver struct {
+	mock.Mock
+}
+
+// SaveDomain provides a mock function with given fields: ctx, host
+func (_m *DomainSaver) SaveDomain(ctx context.Context, host string) (storage.Domain, error) {
+	ret := _m.Called(ctx, host)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveDomain")
+	}
+
+	var r0 storage.Domain
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Domain, error)); ok {
+		return rf(ctx, host)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Domain); ok {
+		r0 = rf(ctx, host)
+	} else {
+		r0 = ret.Get(0).(storage.Domain)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, host)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewDomainSaver creates a new instance of DomainSaver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDomainSaver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DomainSaver {
+	mock := &DomainSaver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
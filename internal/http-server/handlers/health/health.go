@@ -0,0 +1,108 @@
+package health
+
+import (
+	"analiticsURLShortener/internal/lib/api/response"
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	CheckOK     = "ok"
+	CheckFailed = "failed"
+)
+
+// Check is a single readiness dependency check.
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ReadinessResponse struct {
+	response.Response
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// State tracks whether the service is shutting down.
+type State struct {
+	shuttingDown atomic.Bool
+}
+
+// SetShuttingDown makes readiness fail from now on, so that load balancers
+// stop sending new requests before the server stops.
+func (s *State) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+func (s *State) ShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// NewLiveness reports that the process is running. It doesn't check any
+// dependency, so a broken database doesn't get the service restarted.
+func NewLiveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, response.OK())
+	}
+}
+
+// NewReadiness runs all checks concurrently, each bounded by timeout, and
+// answers 503 if any of them fails or the service is shutting down.
+func NewReadiness(log *slog.Logger, state *State, timeout time.Duration, checks ...Check) http.HandlerFunc {
+	log = log.With(slog.String("op", "handlers.health.NewReadiness"))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := make(map[string]CheckResult, len(checks)+1)
+
+		if state.ShuttingDown() {
+			results["shutdown"] = CheckResult{Status: CheckFailed, Error: "server is shutting down"}
+		} else {
+			results["shutdown"] = CheckResult{Status: CheckOK}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, c := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				res := CheckResult{Status: CheckOK}
+				if err := c.Fn(ctx); err != nil {
+					res = CheckResult{Status: CheckFailed, Error: err.Error()}
+				}
+
+				mu.Lock()
+				results[c.Name] = res
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		resp := ReadinessResponse{Response: response.OK(), Checks: results}
+		for name, res := range results {
+			if res.Status != CheckOK {
+				resp.Response = response.Error("not ready")
+				log.Warn("readiness check failed", slog.String("check", name), slog.String("error", res.Error))
+			}
+		}
+
+		if resp.Status != response.StatusOK {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, resp)
+	}
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLiveness(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewLiveness().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status":"OK"}`, recorder.Body.String())
+}
+
+func TestNewReadiness(t *testing.T) {
+	ok := Check{Name: "database", Fn: func(context.Context) error { return nil }}
+	failing := Check{Name: "schema", Fn: func(context.Context) error { return errors.New("schema version is 8, want 9") }}
+	slow := Check{Name: "slow", Fn: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	tests := []struct {
+		name         string
+		shuttingDown bool
+		checks       []Check
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Ready",
+			checks:       []Check{ok},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","checks":{"shutdown":{"status":"ok"},"database":{"status":"ok"}}}`,
+		},
+		{
+			name:         "Check failed",
+			checks:       []Check{ok, failing},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: `{"status":"Error","error":"not ready","checks":{
+				"shutdown":{"status":"ok"},
+				"database":{"status":"ok"},
+				"schema":{"status":"failed","error":"schema version is 8, want 9"}
+			}}`,
+		},
+		{
+			name:         "Check timed out",
+			checks:       []Check{slow},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: `{"status":"Error","error":"not ready","checks":{
+				"shutdown":{"status":"ok"},
+				"slow":{"status":"failed","error":"context deadline exceeded"}
+			}}`,
+		},
+		{
+			name:         "Shutting down",
+			shuttingDown: true,
+			checks:       []Check{ok},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: `{"status":"Error","error":"not ready","checks":{
+				"shutdown":{"status":"failed","error":"server is shutting down"},
+				"database":{"status":"ok"}
+			}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &State{}
+			if tt.shuttingDown {
+				state.SetShuttingDown()
+			}
+
+			recorder := httptest.NewRecorder()
+			NewReadiness(slog.Default(), state, 10*time.Millisecond, tt.checks...).
+				ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
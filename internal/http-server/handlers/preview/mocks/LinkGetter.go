@@ -0,0 +1,116 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "analiticsURLShortener/internal/storage"
+)
+
+// LinkGetter is an autogenerated mock type for the LinkGetter type
+type LinkGetter struct {
+	mock.Mock
+}
+
+// CountClicks provides a mock function with given fields: ctx, alias
+func (_m *LinkGetter) CountClicks(ctx context.Context, alias string) (int64, error) {
+	ret := _m.Called(ctx, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountClicks")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, alias)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetClickSeries provides a mock function with given fields: ctx, alias, q
+func (_m *LinkGetter) GetClickSeries(ctx context.Context, alias string, q storage.AnalyticsQuery) ([]storage.Point, error) {
+	ret := _m.Called(ctx, alias, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetClickSeries")
+	}
+
+	var r0 []storage.Point
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.AnalyticsQuery) ([]storage.Point, error)); ok {
+		return rf(ctx, alias, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.AnalyticsQuery) []storage.Point); ok {
+		r0 = rf(ctx, alias, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Point)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, storage.AnalyticsQuery) error); ok {
+		r1 = rf(ctx, alias, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLink provides a mock function with given fields: ctx, alias
+func (_m *LinkGetter) GetLink(ctx context.Context, alias string) (storage.Link, error) {
+	ret := _m.Called(ctx, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLink")
+	}
+
+	var r0 storage.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Link, error)); ok {
+		return rf(ctx, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Link); ok {
+		r0 = rf(ctx, alias)
+	} else {
+		r0 = ret.Get(0).(storage.Link)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewLinkGetter creates a new instance of LinkGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLinkGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LinkGetter {
+	mock := &LinkGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
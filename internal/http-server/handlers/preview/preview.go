@@ -0,0 +1,182 @@
+package preview
+
+import (
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/lib/shortlink"
+	"analiticsURLShortener/internal/lib/timeseries"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	FormatHTML = "html"
+	FormatJSON = "json"
+
+	lastWeekDays = 7
+)
+
+//go:embed preview.html
+var page string
+
+var tmpl = template.Must(template.New("preview").Parse(page))
+
+type Response struct {
+	response.Response
+	Alias     string    `json:"alias"`
+	Domain    string    `json:"domain,omitempty"`
+	ShortURL  string    `json:"short_url"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+	// Clicks is null for links that aren't embeddable.
+	Clicks *Clicks `json:"clicks"`
+}
+
+type Clicks struct {
+	Total    int64 `json:"total"`
+	LastWeek int64 `json:"last_7_days"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=LinkGetter
+type LinkGetter interface {
+	GetLink(ctx context.Context, alias string) (storage.Link, error)
+	CountClicks(ctx context.Context, alias string) (int64, error)
+	GetClickSeries(ctx context.Context, alias string, q storage.AnalyticsQuery) ([]storage.Point, error)
+}
+
+// New shows where a link goes without following it or recording a click, for
+// /s/{alias}+ and /s/{alias}/info. It renders HTML unless JSON is asked for
+// with format=json, a .json suffix or the Accept header. Click counts are
+// only shown for embeddable links.
+func New(log *slog.Logger, linkGetter LinkGetter, links *shortlink.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.preview.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		alias := strings.TrimSuffix(chi.URLParam(r, "short_url"), shortlink.PreviewSuffix)
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		format := formatOf(r)
+		if format != FormatHTML && format != FormatJSON {
+			log.Info("invalid format", slog.String("format", format))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("format must be html or json"))
+			return
+		}
+
+		link, err := linkGetter.GetLink(r.Context(), alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get link", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		resp := Response{
+			Response:  response.OK(),
+			Alias:     link.Alias,
+			Domain:    link.Domain,
+			ShortURL:  links.URL(r, link.Domain, link.Alias),
+			URL:       link.URL,
+			CreatedAt: link.CreatedAt,
+		}
+
+		if link.Options.Embeddable {
+			clicks, err := countClicks(r.Context(), linkGetter, alias)
+			if err != nil {
+				log.Error("failed to count clicks", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("internal error"))
+				return
+			}
+			resp.Clicks = &clicks
+		}
+
+		if format == FormatJSON {
+			render.JSON(w, r, resp)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, resp); err != nil {
+			log.Error("failed to render preview", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := buf.WriteTo(w); err != nil {
+			log.Info("failed to write preview", sl.Err(err))
+		}
+	}
+}
+
+func formatOf(r *http.Request) string {
+	if ext, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); ext != "" {
+		return ext
+	}
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		return FormatJSON
+	}
+
+	return FormatHTML
+}
+
+func countClicks(ctx context.Context, linkGetter LinkGetter, alias string) (Clicks, error) {
+	var clicks Clicks
+
+	total, err := linkGetter.CountClicks(ctx, alias)
+	if err != nil {
+		return clicks, err
+	}
+	clicks.Total = total
+
+	now := time.Now()
+	series, err := linkGetter.GetClickSeries(ctx, alias, storage.AnalyticsQuery{
+		From:        timeseries.Truncate(now, timeseries.Day, time.UTC).AddDate(0, 0, 1-lastWeekDays),
+		To:          now,
+		Granularity: timeseries.Day,
+		Location:    time.UTC,
+	})
+	if err != nil {
+		return clicks, err
+	}
+	for _, p := range series {
+		clicks.LastWeek += p.Clicks
+	}
+
+	return clicks, nil
+}
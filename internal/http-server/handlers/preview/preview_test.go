@@ -0,0 +1,139 @@
+package preview
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/preview/mocks"
+	"analiticsURLShortener/internal/lib/shortlink"
+	"analiticsURLShortener/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	createdAt := time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC)
+	public := storage.Link{Alias: "promo", URL: "https://example.com/promo", CreatedAt: createdAt,
+		Options: storage.LinkOptions{Embeddable: true}}
+	private := storage.Link{Alias: "promo", URL: "https://example.com/promo", CreatedAt: createdAt}
+
+	tests := []struct {
+		name         string
+		target       string
+		accept       string
+		link         storage.Link
+		linkError    error
+		countError   error
+		expectedCode int
+		expectedJSON string
+		contains     []string
+	}{
+		{
+			name:         "JSON with clicks",
+			target:       "/s/promo/info.json",
+			link:         public,
+			expectedCode: http.StatusOK,
+			expectedJSON: `{"status":"OK","alias":"promo","short_url":"https://sho.rt/s/promo","url":"https://example.com/promo",
+				"created_at":"2025-08-11T10:00:00Z","clicks":{"total":42,"last_7_days":5}}`,
+		},
+		{
+			name:         "JSON by Accept header",
+			target:       "/s/promo+",
+			accept:       "application/json",
+			link:         private,
+			expectedCode: http.StatusOK,
+			expectedJSON: `{"status":"OK","alias":"promo","short_url":"https://sho.rt/s/promo","url":"https://example.com/promo",
+				"created_at":"2025-08-11T10:00:00Z","clicks":null}`,
+		},
+		{
+			name:         "HTML",
+			target:       "/s/promo+",
+			link:         public,
+			expectedCode: http.StatusOK,
+			contains: []string{
+				"<h1>https://sho.rt/s/promo</h1>",
+				`<a href="https://example.com/promo" rel="nofollow noopener">`,
+				"August 11, 2025 10:00 UTC",
+				"42 in total, 5 in the last 7 days",
+			},
+		},
+		{
+			name:         "HTML of private link",
+			target:       "/s/promo/info",
+			link:         private,
+			expectedCode: http.StatusOK,
+			contains:     []string{"The statistics of this link are private."},
+		},
+		{
+			name:         "Not found",
+			target:       "/s/promo+",
+			linkError:    storage.ErrURLNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedJSON: `{"status":"Error","error":"not found"}`,
+		},
+		{
+			name:         "Count error",
+			target:       "/s/promo+",
+			link:         public,
+			countError:   errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedJSON: `{"status":"Error","error":"internal error"}`,
+		},
+		{
+			name:         "Invalid format",
+			target:       "/s/promo/info?format=xml",
+			expectedCode: http.StatusBadRequest,
+			expectedJSON: `{"status":"Error","error":"format must be html or json"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := mocks.NewLinkGetter(t)
+			if tt.expectedCode != http.StatusBadRequest {
+				getter.On("GetLink", mock.Anything, "promo").Return(tt.link, tt.linkError).Once()
+			}
+			if tt.link.Options.Embeddable {
+				getter.On("CountClicks", mock.Anything, "promo").Return(int64(42), tt.countError).Once()
+			}
+			if tt.link.Options.Embeddable && tt.countError == nil {
+				getter.On("GetClickSeries", mock.Anything, "promo", mock.AnythingOfType("storage.AnalyticsQuery")).
+					Return([]storage.Point{{Clicks: 2}, {Clicks: 3}}, nil).Once()
+			}
+
+			links, err := shortlink.New("https://sho.rt", "/s/")
+			require.NoError(t, err)
+
+			handler := New(slog.Default(), getter, links)
+			router := chi.NewRouter()
+			router.Use(middleware.URLFormat)
+			router.Get("/s/{short_url}", handler)
+			router.Get("/s/{short_url}/info", handler)
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedJSON != "" {
+				assert.JSONEq(t, tt.expectedJSON, recorder.Body.String())
+			}
+			if len(tt.contains) > 0 {
+				assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+			}
+			for _, s := range tt.contains {
+				assert.Contains(t, recorder.Body.String(), s)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package qr
+
+import (
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/lib/qrcode"
+	"analiticsURLShortener/internal/lib/shortlink"
+	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	// Source is the src parameter appended to encoded links, so that scans
+	// are counted as a separate traffic source.
+	Source = "qr"
+
+	defaultSize   = 256
+	minSize       = 64
+	maxSize       = 2048
+	defaultMargin = 4
+	maxMargin     = 16
+	// codes only change with the public base URL, so they are cached for long
+	cacheControl = "public, max-age=86400"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=URLGetter
+type URLGetter interface {
+	GetURL(ctx context.Context, alias string) (string, error)
+}
+
+// New returns a QR code of the public short URL of a link as PNG or, with
+// format=svg or a .svg suffix, as SVG. The size, level, margin, fg and bg
+// parameters change the image.
+func New(log *slog.Logger, urlGetter URLGetter, links *shortlink.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.qr.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "short_url")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if ext, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); ext != "" {
+			format = ext
+		}
+		if format == "" {
+			format = FormatPNG
+		}
+		if format != FormatPNG && format != FormatSVG {
+			log.Info("invalid format", slog.String("format", format))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("format must be png or svg"))
+			return
+		}
+
+		opts, err := parseOptions(r.URL.Query())
+		if err != nil {
+			log.Info("invalid qr options", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		_, err = urlGetter.GetURL(r.Context(), alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", "alias", alias)
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		var buf bytes.Buffer
+		code, err := qrcode.New(encodedURL(r, links, alias), opts)
+		if err == nil {
+			if format == FormatSVG {
+				err = code.SVG(&buf)
+			} else {
+				err = code.PNG(&buf)
+			}
+		}
+		if err != nil {
+			log.Error("failed to draw qr code", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("internal error"))
+			return
+		}
+
+		if format == FormatSVG {
+			w.Header().Set("Content-Type", "image/svg+xml")
+		} else {
+			w.Header().Set("Content-Type", "image/png")
+		}
+		w.Header().Set("Cache-Control", cacheControl)
+		if _, err := buf.WriteTo(w); err != nil {
+			log.Info("failed to write qr code", sl.Err(err))
+		}
+	}
+}
+
+// encodedURL returns the short URL of alias on the domain of r, tagged as
+// scanned from a QR code.
+func encodedURL(r *http.Request, links *shortlink.Builder, alias string) string {
+	return links.URL(r, storage.DomainFromContext(r.Context()), alias) + "?src=" + Source
+}
+
+func parseOptions(params url.Values) (qrcode.Options, error) {
+	opts := qrcode.Options{
+		Size:       defaultSize,
+		Level:      qrcode.LevelMedium,
+		Margin:     defaultMargin,
+		Foreground: color.Black,
+		Background: color.White,
+	}
+
+	var err error
+	if v := params.Get("size"); v != "" {
+		if opts.Size, err = strconv.Atoi(v); err != nil || opts.Size < minSize || opts.Size > maxSize {
+			return opts, fmt.Errorf("size must be between %d and %d", minSize, maxSize)
+		}
+	}
+	if v := params.Get("margin"); v != "" {
+		if opts.Margin, err = strconv.Atoi(v); err != nil || opts.Margin < 0 || opts.Margin > maxMargin {
+			return opts, fmt.Errorf("margin must be between 0 and %d", maxMargin)
+		}
+	}
+	if v := params.Get("level"); v != "" {
+		if opts.Level, err = qrcode.ParseLevel(v); err != nil {
+			return opts, errors.New("level must be L, M, Q or H")
+		}
+	}
+	if v := params.Get("fg"); v != "" {
+		if opts.Foreground, err = qrcode.ParseColor(v); err != nil {
+			return opts, err
+		}
+	}
+	if v := params.Get("bg"); v != "" {
+		if opts.Background, err = qrcode.ParseColor(v); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
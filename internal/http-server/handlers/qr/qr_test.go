@@ -0,0 +1,127 @@
+package qr
+
+import (
+	"analiticsURLShortener/internal/http-server/handlers/qr/mocks"
+	"analiticsURLShortener/internal/lib/shortlink"
+	"analiticsURLShortener/internal/storage"
+	"errors"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		mockError    error
+		expectedCode int
+		expectedType string
+		expectedBody string
+	}{
+		{
+			name:         "PNG",
+			target:       "/qr/promo?size=128&margin=2&level=H",
+			expectedCode: http.StatusOK,
+			expectedType: "image/png",
+		},
+		{
+			name:         "SVG suffix",
+			target:       "/qr/promo.svg?fg=1f2328&bg=fff",
+			expectedCode: http.StatusOK,
+			expectedType: "image/svg+xml",
+		},
+		{
+			name:         "Not found",
+			target:       "/qr/promo",
+			mockError:    storage.ErrURLNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"status":"Error","error":"not found"}`,
+		},
+		{
+			name:         "Internal error",
+			target:       "/qr/promo",
+			mockError:    errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status":"Error","error":"internal error"}`,
+		},
+		{
+			name:         "Invalid format",
+			target:       "/qr/promo?format=gif",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"format must be png or svg"}`,
+		},
+		{
+			name:         "Invalid size",
+			target:       "/qr/promo?size=5000",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"size must be between 64 and 2048"}`,
+		},
+		{
+			name:         "Invalid level",
+			target:       "/qr/promo?level=X",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"level must be L, M, Q or H"}`,
+		},
+		{
+			name:         "Invalid color",
+			target:       "/qr/promo?fg=red",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"invalid color \"red\""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := mocks.NewURLGetter(t)
+			if tt.expectedCode != http.StatusBadRequest {
+				getter.On("GetURL", mock.Anything, "promo").Return("https://example.com", tt.mockError).Once()
+			}
+
+			links, err := shortlink.New("https://sho.rt", "/s/")
+			require.NoError(t, err)
+
+			router := chi.NewRouter()
+			router.Use(middleware.URLFormat)
+			router.Get("/qr/{short_url}", New(slog.Default(), getter, links))
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+				return
+			}
+
+			assert.Equal(t, tt.expectedType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, "public, max-age=86400", recorder.Header().Get("Cache-Control"))
+			if tt.expectedType == "image/png" {
+				img, err := png.Decode(recorder.Body)
+				require.NoError(t, err)
+				assert.Equal(t, 128, img.Bounds().Dx())
+			} else {
+				assert.Contains(t, recorder.Body.String(), `fill="#1f2328"`)
+			}
+		})
+	}
+}
+
+func TestEncodedURL(t *testing.T) {
+	links, err := shortlink.New("https://sho.rt", "/s/")
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/qr/promo", nil)
+	assert.Equal(t, "https://sho.rt/s/promo?src=qr", encodedURL(r, links, "promo"))
+
+	r = r.WithContext(storage.WithDomain(r.Context(), "brand.example"))
+	assert.Equal(t, "https://brand.example/s/promo?src=qr", encodedURL(r, links, "promo"))
+}
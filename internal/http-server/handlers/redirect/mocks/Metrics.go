@@ -0,0 +1,39 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// Metrics is an autogenerated mock type for the Metrics type
+type Metrics struct {
+	mock.Mock
+}
+
+// AnalyticsWriteFailed provides a mock function with no fields
+func (_m *Metrics) AnalyticsWriteFailed() {
+	_m.Called()
+}
+
+// RedirectHit provides a mock function with no fields
+func (_m *Metrics) RedirectHit() {
+	_m.Called()
+}
+
+// RedirectNotFound provides a mock function with no fields
+func (_m *Metrics) RedirectNotFound() {
+	_m.Called()
+}
+
+// NewMetrics creates a new instance of Metrics. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMetrics(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Metrics {
+	mock := &Metrics{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
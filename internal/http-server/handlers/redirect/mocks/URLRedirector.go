@@ -2,16 +2,22 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "analiticsURLShortener/internal/storage"
+)
 
 // URLRedirector is an autogenerated mock type for the URLRedirector type
 type URLRedirector struct {
 	mock.Mock
 }
 
-// GetURL provides a mock function with given fields: alias
-func (_m *URLRedirector) GetURL(alias string) (string, error) {
-	ret := _m.Called(alias)
+// GetURL provides a mock function with given fields: ctx, alias
+func (_m *URLRedirector) GetURL(ctx context.Context, alias string) (string, error) {
+	ret := _m.Called(ctx, alias)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetURL")
@@ -19,17 +25,17 @@ func (_m *URLRedirector) GetURL(alias string) (string, error) {
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, alias)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, alias)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alias)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -37,17 +43,17 @@ func (_m *URLRedirector) GetURL(alias string) (string, error) {
 	return r0, r1
 }
 
-// SaveAnalytics provides a mock function with given fields: alias, userAgent
-func (_m *URLRedirector) SaveAnalytics(alias string, userAgent string) error {
-	ret := _m.Called(alias, userAgent)
+// SaveAnalytics provides a mock function with given fields: ctx, alias, click
+func (_m *URLRedirector) SaveAnalytics(ctx context.Context, alias string, click storage.Click) error {
+	ret := _m.Called(ctx, alias, click)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveAnalytics")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(alias, userAgent)
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Click) error); ok {
+		r0 = rf(ctx, alias, click)
 	} else {
 		r0 = ret.Error(0)
 	}
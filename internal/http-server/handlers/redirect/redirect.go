@@ -1,32 +1,49 @@
 package redirect
 
 import (
+	"context"
 	"errors"
 	"github.com/go-chi/chi/v5"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"log/slog"
 
+	"analiticsURLShortener/internal/http-server/middleware/realip"
 	resp "analiticsURLShortener/internal/lib/api/response"
 	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/lib/referrer"
+	"analiticsURLShortener/internal/lib/shortlink"
 	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=URLRedirector
 type URLRedirector interface {
-	GetURL(alias string) (string, error)
-	SaveAnalytics(alias string, userAgent string) error
+	GetURL(ctx context.Context, alias string) (string, error)
+	SaveAnalytics(ctx context.Context, alias string, click storage.Click) error
 }
 
-func New(log *slog.Logger, urlRedirector URLRedirector) http.HandlerFunc {
+//go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=Metrics
+type Metrics interface {
+	RedirectHit()
+	RedirectNotFound()
+	AnalyticsWriteFailed()
+}
+
+// New redirects to the URL of a link and records the click. Aliases with
+// shortlink.PreviewSuffix are handed to preview instead, without counting a
+// click; a nil preview reports them as not found.
+func New(log *slog.Logger, urlRedirector URLRedirector, metrics Metrics, preview http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
 		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
 		alias := chi.URLParam(r, "short_url")
@@ -38,9 +55,16 @@ func New(log *slog.Logger, urlRedirector URLRedirector) http.HandlerFunc {
 			return
 		}
 
-		resURL, err := urlRedirector.GetURL(alias)
+		if preview != nil && strings.HasSuffix(alias, shortlink.PreviewSuffix) {
+			preview.ServeHTTP(w, r)
+
+			return
+		}
+
+		resURL, err := urlRedirector.GetURL(r.Context(), alias)
 		if errors.Is(err, storage.ErrURLNotFound) {
 			log.Info("url not found", "alias", alias)
+			metrics.RedirectNotFound()
 			render.Status(r, http.StatusNotFound)
 			render.JSON(w, r, resp.Error("not found"))
 
@@ -54,10 +78,18 @@ func New(log *slog.Logger, urlRedirector URLRedirector) http.HandlerFunc {
 			return
 		}
 
-		userAgent := r.UserAgent()
-		err = urlRedirector.SaveAnalytics(alias, userAgent)
+		metrics.RedirectHit()
+
+		click := storage.Click{
+			UserAgent: r.UserAgent(),
+			Referrer:  referrer.Domain(r.Referer()),
+			Source:    referrer.Source(r),
+			IP:        realip.ClientIP(r),
+		}
+		err = urlRedirector.SaveAnalytics(r.Context(), alias, click)
 		if err != nil {
 			log.Error("failed to save analytics", sl.Err(err))
+			metrics.AnalyticsWriteFailed()
 		}
 
 		log.Info("got url", slog.String("url", resURL))
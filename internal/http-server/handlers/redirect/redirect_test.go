@@ -85,14 +85,25 @@ func TestNew(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRedirector := mocks.NewURLRedirector(t)
+			mockMetrics := mocks.NewMetrics(t)
+
+			switch tt.expectedCode {
+			case http.StatusFound:
+				mockMetrics.On("RedirectHit").Once()
+				if tt.mockSaveError != nil {
+					mockMetrics.On("AnalyticsWriteFailed").Once()
+				}
+			case http.StatusNotFound:
+				mockMetrics.On("RedirectNotFound").Once()
+			}
 
 			// Мокируем вызовы GetURL и SaveAnalytics только для тех кейсов, где они ожидаются
 			if tt.alias != "" {
 				if tt.name != "URL Not Found" && tt.name != "Internal Error" {
-					mockRedirector.On("GetURL", tt.alias).Return(tt.mockGetURL, tt.mockGetError).Once()
-					mockRedirector.On("SaveAnalytics", tt.alias, mock.AnythingOfType("string")).Return(tt.mockSaveError).Once()
+					mockRedirector.On("GetURL", mock.Anything, tt.alias).Return(tt.mockGetURL, tt.mockGetError).Once()
+					mockRedirector.On("SaveAnalytics", mock.Anything, tt.alias, mock.AnythingOfType("storage.Click")).Return(tt.mockSaveError).Once()
 				} else {
-					mockRedirector.On("GetURL", tt.alias).Return(tt.mockGetURL, tt.mockGetError).Once()
+					mockRedirector.On("GetURL", mock.Anything, tt.alias).Return(tt.mockGetURL, tt.mockGetError).Once()
 				}
 			}
 
@@ -107,7 +118,7 @@ func TestNew(t *testing.T) {
 			}
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-			handler := New(slog.Default(), mockRedirector)
+			handler := New(slog.Default(), mockRedirector, mockMetrics, nil)
 			handler.ServeHTTP(recorder, req)
 
 			assert.Equal(t, tt.expectedCode, recorder.Code)
@@ -128,3 +139,50 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestNew_ClickAttributes(t *testing.T) {
+	mockRedirector := mocks.NewURLRedirector(t)
+	mockRedirector.On("GetURL", mock.Anything, "promo").Return("https://go.dev", nil).Once()
+	mockRedirector.On("SaveAnalytics", mock.Anything, "promo", storage.Click{
+		UserAgent: "test-agent",
+		Referrer:  "news.ycombinator.com",
+		Source:    "newsletter",
+		IP:        "192.0.2.1",
+	}).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/s/promo?utm_source=newsletter", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Referer", "https://news.ycombinator.com/item?id=1")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("short_url", "promo")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	mockMetrics := mocks.NewMetrics(t)
+	mockMetrics.On("RedirectHit").Once()
+
+	recorder := httptest.NewRecorder()
+	New(slog.Default(), mockRedirector, mockMetrics, nil).ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusFound, recorder.Code)
+}
+
+func TestNew_Preview(t *testing.T) {
+	var previewed string
+	preview := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		previewed = chi.URLParam(r, "short_url")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/s/promo+", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("short_url", "promo+")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	// no click is recorded, so the mocks expect no calls
+	recorder := httptest.NewRecorder()
+	New(slog.Default(), mocks.NewURLRedirector(t), mocks.NewMetrics(t), preview).ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "promo+", previewed)
+}
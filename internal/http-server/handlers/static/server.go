@@ -0,0 +1,179 @@
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// MaxAge is the Cache-Control max-age of assets other than HTML pages,
+	// which are always revalidated with their ETag.
+	MaxAge time.Duration
+	// Reload reads files on every request and makes clients revalidate
+	// everything, for editing assets on disk.
+	Reload bool
+}
+
+// asset is a file prepared for serving: its content, precompressed variant
+// if any, and their ETags.
+type asset struct {
+	contentType string
+	data        []byte
+	etag        string
+	gz          []byte
+	gzETag      string
+}
+
+type server struct {
+	fsys   fs.FS
+	opts   Options
+	assets sync.Map
+}
+
+// New serves the files of fsys with ETag and Cache-Control headers. A file
+// with a name.gz sibling holding the same content gzipped is served from it
+// to clients accepting gzip. Directories are served by their index.html.
+func New(fsys fs.FS, opts Options) http.Handler {
+	return &server{fsys: fsys, opts: opts}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+
+	a, err := s.asset(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", a.contentType)
+	h.Set("Cache-Control", s.cacheControl(a))
+
+	data, etag := a.data, a.etag
+	if a.gz != nil {
+		h.Add("Vary", "Accept-Encoding")
+		if acceptsGzip(r) {
+			h.Set("Content-Encoding", "gzip")
+			data, etag = a.gz, a.gzETag
+		}
+	}
+	h.Set("ETag", etag)
+
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+func (s *server) cacheControl(a *asset) string {
+	if s.opts.Reload || s.opts.MaxAge <= 0 || strings.HasPrefix(a.contentType, "text/html") {
+		return "no-cache"
+	}
+
+	return fmt.Sprintf("public, max-age=%d", int(s.opts.MaxAge.Seconds()))
+}
+
+func (s *server) asset(name string) (*asset, error) {
+	if !s.opts.Reload {
+		if a, ok := s.assets.Load(name); ok {
+			return a.(*asset), nil
+		}
+	}
+
+	a, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.opts.Reload {
+		s.assets.Store(name, a)
+	}
+
+	return a, nil
+}
+
+func (s *server) load(name string) (*asset, error) {
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fs.ErrNotExist
+	}
+
+	data, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &asset{
+		contentType: mime.TypeByExtension(path.Ext(name)),
+		data:        data,
+		etag:        etag(data),
+	}
+	if a.contentType == "" {
+		a.contentType = http.DetectContentType(data)
+	}
+
+	gz, err := fs.ReadFile(s.fsys, name+".gz")
+	if err == nil && gunzips(gz, data) {
+		a.gz = gz
+		a.gzETag = etag(gz)
+	}
+
+	return a, nil
+}
+
+// gunzips reports whether gz decompresses to data, so that a variant left
+// behind after editing the original is never served.
+func gunzips(gz, data []byte) bool {
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return false
+	}
+
+	plain, err := io.ReadAll(zr)
+	return err == nil && bytes.Equal(plain, data)
+}
+
+func etag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(coding) != "gzip" {
+			continue
+		}
+
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+
+	return false
+}
@@ -0,0 +1,157 @@
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestNew(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":    {Data: []byte("<html></html>")},
+		"index.html.gz": {Data: gzipped(t, "<html></html>")},
+		"app.js":        {Data: []byte("console.log(2)")},
+		"app.js.gz":     {Data: gzipped(t, "console.log(1)")},
+		"img/logo.svg":  {Data: []byte("<svg/>")},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		reload         bool
+		expectedCode   int
+		expectedType   string
+		expectedCache  string
+		expectedGzip   bool
+	}{
+		{
+			name:          "Index",
+			path:          "/",
+			expectedCode:  http.StatusOK,
+			expectedType:  "text/html; charset=utf-8",
+			expectedCache: "no-cache",
+		},
+		{
+			name:           "Precompressed",
+			path:           "/index.html",
+			acceptEncoding: "br, gzip;q=0.8",
+			expectedCode:   http.StatusOK,
+			expectedType:   "text/html; charset=utf-8",
+			expectedCache:  "no-cache",
+			expectedGzip:   true,
+		},
+		{
+			name:           "Gzip refused",
+			path:           "/index.html",
+			acceptEncoding: "gzip;q=0",
+			expectedCode:   http.StatusOK,
+			expectedType:   "text/html; charset=utf-8",
+			expectedCache:  "no-cache",
+		},
+		{
+			name:           "Stale variant skipped",
+			path:           "/app.js",
+			acceptEncoding: "gzip",
+			expectedCode:   http.StatusOK,
+			expectedType:   "text/javascript; charset=utf-8",
+			expectedCache:  "public, max-age=3600",
+		},
+		{
+			name:          "Nested asset",
+			path:          "/img/logo.svg",
+			expectedCode:  http.StatusOK,
+			expectedType:  "image/svg+xml",
+			expectedCache: "public, max-age=3600",
+		},
+		{
+			name:          "Reload",
+			path:          "/img/logo.svg",
+			reload:        true,
+			expectedCode:  http.StatusOK,
+			expectedType:  "image/svg+xml",
+			expectedCache: "no-cache",
+		},
+		{
+			name:         "Not found",
+			path:         "/missing.js",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Directory without index",
+			path:         "/img",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := New(fsys, Options{MaxAge: time.Hour, Reload: tt.reload})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			require.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, tt.expectedType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedCache, recorder.Header().Get("Cache-Control"))
+			assert.NotEmpty(t, recorder.Header().Get("ETag"))
+
+			if tt.expectedGzip {
+				assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+				assert.Equal(t, fsys["index.html.gz"].Data, recorder.Body.Bytes())
+			} else {
+				assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+			}
+		})
+	}
+}
+
+func TestNew_ETag(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":    {Data: []byte("console.log(1)")},
+		"app.js.gz": {Data: gzipped(t, "console.log(1)")},
+	}
+	handler := New(fsys, Options{MaxAge: time.Hour})
+
+	get := func(etag, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/app.js", nil)
+		req.Header.Set("If-None-Match", etag)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	plain := get("", "").Header().Get("ETag")
+	compressed := get("", "gzip").Header().Get("ETag")
+	assert.NotEqual(t, plain, compressed)
+	assert.Equal(t, "Accept-Encoding", get("", "").Header().Get("Vary"))
+
+	assert.Equal(t, http.StatusNotModified, get(plain, "").Code)
+	assert.Equal(t, http.StatusNotModified, get(compressed, "gzip").Code)
+	assert.Equal(t, http.StatusOK, get(plain, "gzip").Code)
+}
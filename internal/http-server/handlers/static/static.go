@@ -0,0 +1,44 @@
+package static
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// TopLevelNames returns the names of the files and directories at the root
+// of fsys. Root-level aliases must not take them.
+func TopLevelNames(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list static files: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return names, nil
+}
+
+// Fallback serves requests for paths that exist in fsys with files and passes
+// the rest to next. It lets root-level aliases coexist with static assets,
+// which take precedence.
+func Fallback(fsys fs.FS, files, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if name == "" || !fs.ValidPath(name) {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		if _, err := fs.Stat(fsys, name); err == nil {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
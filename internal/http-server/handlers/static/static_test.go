@@ -0,0 +1,53 @@
+package static
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFS = fstest.MapFS{
+	"index.html":   {Data: []byte("<html></html>")},
+	"app.js":       {Data: []byte("console.log(1)")},
+	"img/logo.svg": {Data: []byte("<svg/>")},
+}
+
+func TestTopLevelNames(t *testing.T) {
+	names, err := TopLevelNames(testFS)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"index.html", "app.js", "img"}, names)
+}
+
+func TestFallback(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "alias")
+	})
+	handler := Fallback(testFS, http.FileServerFS(testFS), next)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/app.js", want: "console.log(1)"},
+		{path: "/img/logo.svg", want: "<svg/>"},
+		{path: "/", want: "<html></html>"},
+		{path: "/promo", want: "alias"},
+		{path: "/app.js.map", want: "alias"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tt.want, recorder.Body.String())
+		})
+	}
+}
@@ -2,34 +2,68 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "analiticsURLShortener/internal/storage"
+)
 
 // URLSaver is an autogenerated mock type for the URLSaver type
 type URLSaver struct {
 	mock.Mock
 }
 
-// SaveURL provides a mock function with given fields: urlToSave, alias
-func (_m *URLSaver) SaveURL(urlToSave string, alias string) (int64, error) {
-	ret := _m.Called(urlToSave, alias)
+// GetAPIKey provides a mock function with given fields: ctx, prefix
+func (_m *URLSaver) GetAPIKey(ctx context.Context, prefix string) (storage.APIKey, error) {
+	ret := _m.Called(ctx, prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKey")
+	}
+
+	var r0 storage.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.APIKey, error)); ok {
+		return rf(ctx, prefix)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.APIKey); ok {
+		r0 = rf(ctx, prefix)
+	} else {
+		r0 = ret.Get(0).(storage.APIKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveURL provides a mock function with given fields: ctx, urlToSave, alias, opts
+func (_m *URLSaver) SaveURL(ctx context.Context, urlToSave string, alias string, opts storage.LinkOptions) (storage.Link, error) {
+	ret := _m.Called(ctx, urlToSave, alias, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveURL")
 	}
 
-	var r0 int64
+	var r0 storage.Link
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (int64, error)); ok {
-		return rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.LinkOptions) (storage.Link, error)); ok {
+		return rf(ctx, urlToSave, alias, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) int64); ok {
-		r0 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.LinkOptions) storage.Link); ok {
+		r0 = rf(ctx, urlToSave, alias, opts)
 	} else {
-		r0 = ret.Get(0).(int64)
+		r0 = ret.Get(0).(storage.Link)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, storage.LinkOptions) error); ok {
+		r1 = rf(ctx, urlToSave, alias, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
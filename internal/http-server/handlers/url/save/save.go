@@ -2,41 +2,76 @@ package save
 
 import (
 	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/apikey"
+	"analiticsURLShortener/internal/lib/hostname"
 	"analiticsURLShortener/internal/lib/logger/sl"
 	"analiticsURLShortener/internal/lib/random"
+	"analiticsURLShortener/internal/lib/shortlink"
 	"analiticsURLShortener/internal/storage"
+	"analiticsURLShortener/internal/tracing"
+	"context"
 	"errors"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type Request struct {
 	URL   string `json:"url" validate:"required,url"`
 	Alias string `json:"alias,omitempty"`
+	// Domain is the custom domain for the link. It must be the domain of the
+	// API key; empty uses the key's domain.
+	Domain string `json:"domain,omitempty" validate:"omitempty,fqdn"`
+	// Embeddable allows click badges, sparklines and preview click counts of
+	// the link; it doesn't restrict the analytics endpoints.
+	Embeddable bool `json:"embeddable,omitempty"`
 }
 
 type Response struct {
 	response.Response
-	Alias string `json:"alias,omitempty"`
+	Alias     string    `json:"alias,omitempty"`
+	Domain    string    `json:"domain,omitempty"`
+	ShortURL  string    `json:"short_url"`
+	CreatedAt time.Time `json:"created_at"`
+	Options   Options   `json:"options"`
+}
+
+type Options struct {
+	// RawRetention is a duration such as "720h0m0s", "0s" to keep raw clicks
+	// forever, or null for the global retention period.
+	RawRetention *string `json:"raw_retention"`
+	Embeddable   bool    `json:"embeddable"`
 }
 
 const aliasLength = 7
 
+// APIKeyHeader carries the API key of the request. Links of a key are created
+// on its domain, and links on custom domains can only be created with a key.
+const APIKeyHeader = "X-API-Key"
+
+// errInvalidAPIKey is returned for malformed, unknown and mismatching keys.
+var errInvalidAPIKey = errors.New("invalid api key")
+
 //go:generate go run github.com/vektra/mockery/v2@v2.51.1 --name=URLSaver
 type URLSaver interface {
-	SaveURL(urlToSave, alias string) (int64, error)
+	SaveURL(ctx context.Context, urlToSave, alias string, opts storage.LinkOptions) (storage.Link, error)
+	GetAPIKey(ctx context.Context, prefix string) (storage.APIKey, error)
 }
 
-func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
+// New saves short links. Requests without an API key create links on the
+// default domain.
+func New(log *slog.Logger, urlSaver URLSaver, links *shortlink.Builder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
 		var req Request
@@ -66,9 +101,59 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 		alias := req.Alias
 		if alias == "" {
 			alias = random.NewRandomString(aliasLength)
+			for links.IsReserved(alias) {
+				alias = random.NewRandomString(aliasLength)
+			}
+		} else if strings.HasSuffix(alias, shortlink.PreviewSuffix) {
+			log.Info("alias ends with the preview suffix", slog.String("alias", alias))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("alias can't end with "+shortlink.PreviewSuffix))
+
+			return
+		} else if links.IsReserved(alias) {
+			log.Info("alias is reserved", slog.String("alias", alias))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("alias is reserved"))
+
+			return
+		}
+
+		ctx := r.Context()
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			apiKey, err := checkAPIKey(ctx, urlSaver, key)
+			if errors.Is(err, errInvalidAPIKey) {
+				log.Info("invalid api key")
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("invalid api key"))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to get api key", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to add url"))
+
+				return
+			}
+			if req.Domain != "" && hostname.Normalize(req.Domain) != apiKey.Domain {
+				log.Info("domain doesn't match api key", slog.String("domain", req.Domain), slog.String("key_domain", apiKey.Domain))
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("domain doesn't match api key"))
+
+				return
+			}
+			ctx = storage.WithDomain(ctx, apiKey.Domain)
+		} else if req.Domain != "" || storage.DomainFromContext(ctx) != "" {
+			// anyone can send a request to a custom domain, only its keys may
+			// add links to it
+			log.Info("api key required for custom domain")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("api key required"))
+
+			return
 		}
 
-		id, err := urlSaver.SaveURL(req.URL, alias)
+		link, err := urlSaver.SaveURL(ctx, req.URL, alias, storage.LinkOptions{Embeddable: req.Embeddable})
 		if errors.Is(err, storage.ErrURLExists) {
 			log.Info("url already exists", slog.String("url", req.URL))
 			render.Status(r, http.StatusConflict)
@@ -84,15 +169,48 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 			return
 		}
 
-		log.Info("url added", slog.Int64("id", id))
+		log.Info("url added", slog.Int64("id", link.ID))
 
-		responseOK(w, r, alias)
+		responseOK(w, r, link, links.URL(r, link.Domain, link.Alias))
 	}
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, alias string) {
+// checkAPIKey returns the stored key matching key, comparing its secret in
+// constant time.
+func checkAPIKey(ctx context.Context, urlSaver URLSaver, key string) (storage.APIKey, error) {
+	prefix, secret, ok := apikey.Parse(key)
+	if !ok {
+		return storage.APIKey{}, errInvalidAPIKey
+	}
+
+	apiKey, err := urlSaver.GetAPIKey(ctx, prefix)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		return storage.APIKey{}, errInvalidAPIKey
+	}
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+
+	if !apikey.Matches(secret, apiKey.SecretHash) {
+		return storage.APIKey{}, errInvalidAPIKey
+	}
+
+	return apiKey, nil
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, link storage.Link, shortURL string) {
+	opts := Options{Embeddable: link.Options.Embeddable}
+	if link.Options.RawRetention != nil {
+		keep := link.Options.RawRetention.String()
+		opts.RawRetention = &keep
+	}
+
 	render.JSON(w, r, Response{
-		Response: response.OK(),
-		Alias:    alias,
+		Response:  response.OK(),
+		Alias:     link.Alias,
+		Domain:    link.Domain,
+		ShortURL:  shortURL,
+		CreatedAt: link.CreatedAt,
+		Options:   opts,
 	})
 }
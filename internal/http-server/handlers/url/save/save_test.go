@@ -2,6 +2,8 @@ package save
 
 import (
 	"analiticsURLShortener/internal/http-server/handlers/url/save/mocks"
+	"analiticsURLShortener/internal/lib/apikey"
+	"analiticsURLShortener/internal/lib/shortlink"
 	"analiticsURLShortener/internal/storage"
 	"bytes"
 	"context"
@@ -11,11 +13,20 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKeyPrefix = "a1b2c3"
+	testKeySecret = "secret"
+	testKey       = testKeyPrefix + "." + testKeySecret
 )
 
 type testCase struct {
@@ -24,31 +35,165 @@ type testCase struct {
 	alias         string
 	requestBody   string
 	mockError     error
+	retention     *time.Duration
+	host          string
+	apiKey        string
+	keyDomain     string
+	keyError      error
+	embeddable    bool
 	expectedCode  int
 	expectedBody  string
 	expectedAlias string
 }
 
 func TestNew(t *testing.T) {
+	createdAt := time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC)
+	forever := time.Duration(0)
+
 	tests := []testCase{
 		{
-			name:          "Success with alias",
-			url:           "https://example.com",
-			alias:         "test_alias",
-			requestBody:   `{"url": "https://example.com", "alias": "test_alias"}`,
-			mockError:     nil,
-			expectedCode:  http.StatusOK,
-			expectedBody:  `{"status":"OK","alias":"test_alias"}`,
+			name:         "Success with alias",
+			url:          "https://example.com",
+			alias:        "test_alias",
+			requestBody:  `{"url": "https://example.com", "alias": "test_alias"}`,
+			mockError:    nil,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","alias":"test_alias","short_url":"https://sho.rt/s/test_alias",
+				"created_at":"2025-08-11T10:00:00Z","options":{"raw_retention":null,"embeddable":false}}`,
 			expectedAlias: "test_alias",
 		},
 		{
-			name:          "Success without alias",
-			url:           "https://google.com",
-			alias:         "",
-			requestBody:   `{"url": "https://google.com"}`,
-			mockError:     nil,
-			expectedCode:  http.StatusOK,
-			expectedBody:  `{"status":"OK","alias":"%s"}`,
+			name:         "Success with retention override",
+			url:          "https://example.com",
+			alias:        "kept",
+			requestBody:  `{"url": "https://example.com", "alias": "kept"}`,
+			retention:    &forever,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","alias":"kept","short_url":"https://sho.rt/s/kept",
+				"created_at":"2025-08-11T10:00:00Z","options":{"raw_retention":"0s","embeddable":false}}`,
+			expectedAlias: "kept",
+		},
+		{
+			name:         "Success with embeddable link",
+			url:          "https://example.com",
+			alias:        "open",
+			requestBody:  `{"url": "https://example.com", "alias": "open", "embeddable": true}`,
+			embeddable:   true,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","alias":"open","short_url":"https://sho.rt/s/open",
+				"created_at":"2025-08-11T10:00:00Z","options":{"raw_retention":null,"embeddable":true}}`,
+			expectedAlias: "open",
+		},
+		{
+			name:         "Success with API key domain",
+			url:          "https://example.com",
+			alias:        "promo",
+			requestBody:  `{"url": "https://example.com", "alias": "promo"}`,
+			apiKey:       testKey,
+			keyDomain:    "brand.example",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","alias":"promo","domain":"brand.example","short_url":"https://brand.example/s/promo",
+				"created_at":"2025-08-11T10:00:00Z","options":{"raw_retention":null,"embeddable":false}}`,
+			expectedAlias: "promo",
+		},
+		{
+			name:         "Success with API key and its domain",
+			url:          "https://example.com",
+			alias:        "promo",
+			requestBody:  `{"url": "https://example.com", "alias": "promo", "domain": "Brand.example"}`,
+			apiKey:       testKey,
+			keyDomain:    "brand.example",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","alias":"promo","domain":"brand.example","short_url":"https://brand.example/s/promo",
+				"created_at":"2025-08-11T10:00:00Z","options":{"raw_retention":null,"embeddable":false}}`,
+			expectedAlias: "promo",
+		},
+		{
+			name:         "Default domain API key on custom host",
+			url:          "https://example.com",
+			alias:        "promo",
+			requestBody:  `{"url": "https://example.com", "alias": "promo"}`,
+			host:         "brand.example",
+			apiKey:       testKey,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","alias":"promo","short_url":"https://sho.rt/s/promo",
+				"created_at":"2025-08-11T10:00:00Z","options":{"raw_retention":null,"embeddable":false}}`,
+			expectedAlias: "promo",
+		},
+		{
+			name:         "Domain doesn't match API key",
+			url:          "https://example.com",
+			requestBody:  `{"url": "https://example.com", "domain": "other.example"}`,
+			apiKey:       testKey,
+			keyDomain:    "brand.example",
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"status":"Error","error":"domain doesn't match api key"}`,
+		},
+		{
+			name:         "Domain without API key",
+			url:          "https://example.com",
+			requestBody:  `{"url": "https://example.com", "domain": "brand.example"}`,
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"status":"Error","error":"api key required"}`,
+		},
+		{
+			name:         "Custom host without API key",
+			url:          "https://example.com",
+			requestBody:  `{"url": "https://example.com"}`,
+			host:         "brand.example",
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"status":"Error","error":"api key required"}`,
+		},
+		{
+			name:         "Unknown API key",
+			url:          "https://example.com",
+			requestBody:  `{"url": "https://example.com"}`,
+			apiKey:       testKey,
+			keyError:     storage.ErrAPIKeyNotFound,
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"status":"Error","error":"invalid api key"}`,
+		},
+		{
+			name:         "Wrong API key secret",
+			url:          "https://example.com",
+			requestBody:  `{"url": "https://example.com"}`,
+			apiKey:       testKeyPrefix + ".wrong",
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"status":"Error","error":"invalid api key"}`,
+		},
+		{
+			name:         "Malformed API key",
+			url:          "https://example.com",
+			requestBody:  `{"url": "https://example.com"}`,
+			apiKey:       "brand-key",
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"status":"Error","error":"invalid api key"}`,
+		},
+		{
+			name:         "API key storage error",
+			url:          "https://example.com",
+			requestBody:  `{"url": "https://example.com"}`,
+			apiKey:       testKey,
+			keyError:     errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"status":"Error","error":"failed to add url"}`,
+		},
+		{
+			name:         "Invalid domain",
+			url:          "https://example.com",
+			requestBody:  `{"url": "https://example.com", "domain": "not a domain"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"status":"Error","error":"field Domain is not valid"}`,
+		},
+		{
+			name:         "Success without alias",
+			url:          "https://google.com",
+			alias:        "",
+			requestBody:  `{"url": "https://google.com"}`,
+			mockError:    nil,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"OK","alias":"%s","short_url":"%s",
+				"created_at":"2025-08-11T10:00:00Z","options":{"raw_retention":null,"embeddable":false}}`,
 			expectedAlias: "placeholder",
 		},
 		{
@@ -93,18 +238,43 @@ func TestNew(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockURLSaver := mocks.NewURLSaver(t)
 
-			if tt.expectedCode == http.StatusOK || tt.expectedCode == http.StatusConflict || tt.expectedCode == http.StatusInternalServerError {
-				mockURLSaver.On("SaveURL", tt.url, mock.AnythingOfType("string")).Return(int64(1), tt.mockError).Once()
+			if strings.HasPrefix(tt.apiKey, testKeyPrefix+".") {
+				mockURLSaver.On("GetAPIKey", mock.Anything, testKeyPrefix).
+					Return(storage.APIKey{Prefix: testKeyPrefix, SecretHash: apikey.Hash(testKeySecret), Domain: tt.keyDomain}, tt.keyError).Once()
 			}
 
+			saved := tt.expectedCode == http.StatusOK || tt.expectedCode == http.StatusConflict || tt.expectedCode == http.StatusInternalServerError
+			if saved && tt.keyError == nil {
+				mockURLSaver.On("SaveURL", mock.Anything, tt.url, mock.AnythingOfType("string"), storage.LinkOptions{Embeddable: tt.embeddable}).
+					Return(func(ctx context.Context, url, alias string, opts storage.LinkOptions) (storage.Link, error) {
+						return storage.Link{
+							ID:        1,
+							Alias:     alias,
+							Domain:    storage.DomainFromContext(ctx),
+							URL:       url,
+							CreatedAt: createdAt,
+							Options:   storage.LinkOptions{RawRetention: tt.retention, Embeddable: opts.Embeddable},
+						}, tt.mockError
+					}).Once()
+			}
+
+			links, err := shortlink.New("https://sho.rt", "/s/")
+			require.NoError(t, err)
+
 			recorder := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodPost, "/shorten", bytes.NewReader([]byte(tt.requestBody)))
 			req.Header.Set("Content-Type", "application/json")
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
 
 			ctx := context.WithValue(req.Context(), middleware.RequestIDKey, "test-request-id")
+			if tt.host != "" {
+				ctx = storage.WithDomain(ctx, tt.host)
+			}
 			req = req.WithContext(ctx)
 
-			handler := New(slog.Default(), mockURLSaver)
+			handler := New(slog.Default(), mockURLSaver, links)
 			handler.ServeHTTP(recorder, req)
 
 			assert.Equal(t, tt.expectedCode, recorder.Code)
@@ -117,6 +287,7 @@ func TestNew(t *testing.T) {
 			if tt.name == "Success without alias" {
 				assert.NotEmpty(t, actual["alias"])
 				expected["alias"] = actual["alias"]
+				expected["short_url"] = "https://sho.rt/s/" + actual["alias"].(string)
 			}
 
 			assert.Equal(t, expected, actual)
@@ -125,3 +296,30 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestNew_ReservedAlias(t *testing.T) {
+	links, err := shortlink.New("https://sho.rt", "/")
+	require.NoError(t, err)
+	links.Reserve("stats", "app.js")
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/shorten", strings.NewReader(`{"url": "https://example.com", "alias": "stats"}`))
+
+	New(slog.Default(), mocks.NewURLSaver(t), links).ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"status":"Error","error":"alias is reserved"}`, recorder.Body.String())
+}
+
+func TestNew_PreviewSuffix(t *testing.T) {
+	links, err := shortlink.New("https://sho.rt", "/s/")
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/shorten", strings.NewReader(`{"url": "https://example.com", "alias": "promo+"}`))
+
+	New(slog.Default(), mocks.NewURLSaver(t), links).ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"status":"Error","error":"alias can't end with +"}`, recorder.Body.String())
+}
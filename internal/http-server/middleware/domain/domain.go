@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"analiticsURLShortener/internal/lib/api/response"
+	"analiticsURLShortener/internal/lib/hostname"
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/lib/ttlcache"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// maxCachedHosts bounds the cache, since the Host header is client input.
+const maxCachedHosts = 1000
+
+type Resolver interface {
+	GetDomain(ctx context.Context, host string) (storage.Domain, error)
+}
+
+// New scopes aliases of the request to the registered domain matching its
+// Host header, see storage.WithDomain. Requests to any other host use the
+// default domain. Lookups are cached for cacheTTL.
+func New(log *slog.Logger, resolver Resolver, cacheTTL time.Duration) func(next http.Handler) http.Handler {
+	log = log.With(slog.String("component", "middleware/domain"))
+	registered := ttlcache.New[string, bool](cacheTTL, maxCachedHosts)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			host := hostname.Normalize(r.Host)
+
+			ok, cached := registered.Get(host)
+			if !cached {
+				_, err := resolver.GetDomain(r.Context(), host)
+				switch {
+				case err == nil:
+					ok = true
+				case errors.Is(err, storage.ErrDomainNotFound):
+					ok = false
+				default:
+					log.Error("failed to resolve domain", slog.String("host", host), sl.Err(err))
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, response.Error("internal error"))
+
+					return
+				}
+				registered.Set(host, ok)
+			}
+
+			if ok {
+				r = r.WithContext(storage.WithDomain(r.Context(), host))
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
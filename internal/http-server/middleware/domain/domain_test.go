@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResolver struct {
+	hosts map[string]bool
+	err   error
+	calls atomic.Int32
+}
+
+func (f *fakeResolver) GetDomain(_ context.Context, host string) (storage.Domain, error) {
+	f.calls.Add(1)
+	if f.err != nil {
+		return storage.Domain{}, f.err
+	}
+	if !f.hosts[host] {
+		return storage.Domain{}, storage.ErrDomainNotFound
+	}
+	return storage.Domain{ID: 1, Host: host}, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		host         string
+		err          error
+		expectedCode int
+		expected     string
+	}{
+		{
+			name:         "Registered domain",
+			host:         "Brand.example:443",
+			expectedCode: http.StatusOK,
+			expected:     "brand.example",
+		},
+		{
+			name:         "Default domain",
+			host:         "sho.rt",
+			expectedCode: http.StatusOK,
+			expected:     "",
+		},
+		{
+			name:         "Lookup error",
+			host:         "brand.example",
+			err:          errors.New("db is down"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := &fakeResolver{hosts: map[string]bool{"brand.example": true}, err: tt.err}
+
+			var got string
+			handler := New(slog.Default(), resolver, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = storage.DomainFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/s/promo", nil)
+			req.Host = tt.host
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNew_Cache(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string]bool{"brand.example": true}}
+	handler := New(slog.Default(), resolver, time.Minute)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for _, host := range []string{"brand.example", "brand.example", "sho.rt", "sho.rt"} {
+		req := httptest.NewRequest(http.MethodGet, "/s/promo", nil)
+		req.Host = host
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, int32(2), resolver.calls.Load())
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"analiticsURLShortener/internal/tracing"
 	"log/slog"
 	"net/http"
 	"time"
@@ -21,6 +22,7 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 				slog.String("remote_addr", r.RemoteAddr),
 				slog.String("user_agent", r.UserAgent()),
 				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("trace_id", tracing.TraceID(r.Context())),
 			)
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
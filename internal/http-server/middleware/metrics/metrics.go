@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// unmatched labels requests that didn't match any route, so that arbitrary
+// paths can't create new label values.
+const unmatched = "unmatched"
+
+type Recorder interface {
+	ObserveRequest(route, method string, status int, d time.Duration)
+}
+
+// New records every request under its chi route pattern, e.g.
+// "/s/{short_url}", rather than the raw path.
+func New(recorder Recorder) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			start := time.Now()
+			defer func() {
+				route := unmatched
+				if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+					route = rctx.RoutePattern()
+				}
+
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				recorder.ObserveRequest(route, r.Method, status, time.Since(start))
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type observation struct {
+	route, method string
+	status        int
+}
+
+type fakeRecorder struct {
+	observed []observation
+}
+
+func (f *fakeRecorder) ObserveRequest(route, method string, status int, _ time.Duration) {
+	f.observed = append(f.observed, observation{route: route, method: method, status: status})
+}
+
+func TestNew(t *testing.T) {
+	recorder := &fakeRecorder{}
+
+	router := chi.NewRouter()
+	router.Use(New(recorder))
+	router.Get("/s/{short_url}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com", http.StatusFound)
+	})
+	router.Get("/analytics/{short_url}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{}"))
+	})
+
+	for _, target := range []string{"/s/abc", "/s/def", "/analytics/abc", "/nope"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	assert.Equal(t, []observation{
+		{route: "/s/{short_url}", method: http.MethodGet, status: http.StatusFound},
+		{route: "/s/{short_url}", method: http.MethodGet, status: http.StatusFound},
+		{route: "/analytics/{short_url}", method: http.MethodGet, status: http.StatusOK},
+		{route: unmatched, method: http.MethodGet, status: http.StatusNotFound},
+	}, recorder.observed)
+}
@@ -0,0 +1,168 @@
+package realip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// ParseCIDRs parses trusted proxy networks. Bare addresses are treated as
+// single-host networks.
+func ParseCIDRs(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+
+		if strings.Contains(s, "/") {
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+
+			continue
+		}
+
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+// New resolves the client IP from the Forwarded or X-Forwarded-For headers,
+// but only when the request arrives from a trusted proxy. Forwarded hops are
+// walked from the nearest one and the first untrusted address is taken as the
+// client. r.RemoteAddr is replaced with the resolved IP, so everything
+// downstream sees the same address.
+func New(trusted []netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ip := resolve(r, trusted)
+			if ip.IsValid() {
+				r.RemoteAddr = ip.String()
+				r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, ip.String()))
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// FromContext returns the client IP resolved by the middleware.
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ctxKey{}).(string)
+	return ip
+}
+
+// ClientIP returns the resolved client IP for r, falling back to the host
+// part of r.RemoteAddr when the middleware is not installed.
+func ClientIP(r *http.Request) string {
+	if ip := FromContext(r.Context()); ip != "" {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func resolve(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	peer, ok := parseAddr(r.RemoteAddr)
+	if !ok {
+		return netip.Addr{}
+	}
+
+	if !isTrusted(peer, trusted) {
+		return peer
+	}
+
+	hops := forwardedFor(r.Header.Values("Forwarded"))
+	if len(hops) == 0 {
+		hops = xForwardedFor(r.Header.Values("X-Forwarded-For"))
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseAddr(hops[i])
+		if !ok {
+			break
+		}
+
+		client = hop
+		if !isTrusted(hop, trusted) {
+			break
+		}
+	}
+
+	return client
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forwardedFor extracts the "for" parameters of RFC 7239 Forwarded headers.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(value, `"`))
+				}
+			}
+		}
+	}
+
+	return hops
+}
+
+func xForwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	return hops
+}
+
+// parseAddr accepts "ip", "ip:port", "[ipv6]" and "[ipv6]:port".
+func parseAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
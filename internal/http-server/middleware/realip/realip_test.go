@@ -0,0 +1,92 @@
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	trusted, err := ParseCIDRs([]string{"10.0.0.0/8", "192.0.2.10"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expectedIP string
+	}{
+		{
+			name:       "Direct client",
+			remoteAddr: "203.0.113.5:5123",
+			expectedIP: "203.0.113.5",
+		},
+		{
+			name:       "Untrusted peer cannot spoof",
+			remoteAddr: "203.0.113.5:5123",
+			headers:    map[string]string{"X-Forwarded-For": "1.1.1.1"},
+			expectedIP: "203.0.113.5",
+		},
+		{
+			name:       "Trusted proxy with X-Forwarded-For",
+			remoteAddr: "10.0.0.2:40000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.7"},
+			expectedIP: "198.51.100.7",
+		},
+		{
+			name:       "Chain of trusted proxies",
+			remoteAddr: "10.0.0.2:40000",
+			headers:    map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.7, 192.0.2.10"},
+			expectedIP: "198.51.100.7",
+		},
+		{
+			name:       "Forwarded header preferred",
+			remoteAddr: "10.0.0.2:40000",
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8:cafe::17]:4711";proto=https`,
+				"X-Forwarded-For": "198.51.100.7",
+			},
+			expectedIP: "2001:db8:cafe::17",
+		},
+		{
+			name:       "Trusted proxy without headers",
+			remoteAddr: "10.0.0.2:40000",
+			expectedIP: "10.0.0.2",
+		},
+		{
+			name:       "Obfuscated hop stops the walk",
+			remoteAddr: "10.0.0.2:40000",
+			headers:    map[string]string{"Forwarded": "for=198.51.100.7, for=_hidden"},
+			expectedIP: "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAddr, gotCtx string
+			handler := New(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAddr = r.RemoteAddr
+				gotCtx = ClientIP(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expectedIP, gotAddr)
+			assert.Equal(t, tt.expectedIP, gotCtx)
+		})
+	}
+}
+
+func TestParseCIDRs_Invalid(t *testing.T) {
+	_, err := ParseCIDRs([]string{"not-a-network"})
+	assert.Error(t, err)
+}
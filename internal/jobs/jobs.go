@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"analiticsURLShortener/internal/lib/logger/sl"
+	"analiticsURLShortener/internal/tracing"
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("analiticsURLShortener/internal/jobs")
+
+// Job is a periodic background task.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Fn       func(ctx context.Context, now time.Time) error
+}
+
+// Run calls job.Fn immediately and then every job.Interval until ctx is
+// done. Errors are logged and do not stop the job. A non-positive interval
+// disables the job.
+func Run(ctx context.Context, log *slog.Logger, job Job) {
+	log = log.With(slog.String("component", "jobs"), slog.String("job", job.Name))
+
+	if job.Interval <= 0 {
+		log.Info("job disabled")
+		return
+	}
+
+	log.Info("job started", slog.String("interval", job.Interval.String()))
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		runCtx, span := tracer.Start(ctx, "job."+job.Name)
+		if err := job.Fn(runCtx, start); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			log.Error("job failed", sl.Err(err), slog.String("trace_id", tracing.TraceID(runCtx)))
+		} else {
+			log.Debug("job finished", slog.String("duration", time.Since(start).String()))
+		}
+		span.End()
+
+		select {
+		case <-ctx.Done():
+			log.Info("job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	var calls atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		Run(ctx, slog.Default(), Job{
+			Name:     "test",
+			Interval: 5 * time.Millisecond,
+			Fn: func(context.Context, time.Time) error {
+				if calls.Add(1) == 1 {
+					return errors.New("first run fails")
+				}
+				return nil
+			},
+		})
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return calls.Load() >= 3 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop")
+	}
+}
+
+func TestRun_Disabled(t *testing.T) {
+	called := false
+	Run(context.Background(), slog.Default(), Job{
+		Name: "disabled",
+		Fn:   func(context.Context, time.Time) error { called = true; return nil },
+	})
+
+	assert.False(t, called)
+}
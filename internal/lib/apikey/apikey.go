@@ -0,0 +1,51 @@
+// Package apikey generates API keys and checks them against stored hashes.
+// A key is "<prefix>.<secret>": the prefix identifies the key and is stored
+// as is, the secret is only stored as its SHA-256 hash.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	prefixBytes = 6
+	secretBytes = 24
+)
+
+// Generate returns a new key with its prefix and the hash of its secret.
+func Generate() (key, prefix string, hash []byte, err error) {
+	buf := make([]byte, prefixBytes+secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", nil, err
+	}
+
+	prefix = hex.EncodeToString(buf[:prefixBytes])
+	secret := hex.EncodeToString(buf[prefixBytes:])
+
+	return prefix + "." + secret, prefix, Hash(secret), nil
+}
+
+// Parse splits a key into its prefix and secret.
+func Parse(key string) (prefix, secret string, ok bool) {
+	prefix, secret, ok = strings.Cut(key, ".")
+	if !ok || prefix == "" || secret == "" {
+		return "", "", false
+	}
+
+	return prefix, secret, true
+}
+
+// Hash returns the stored form of a secret.
+func Hash(secret string) []byte {
+	h := sha256.Sum256([]byte(secret))
+	return h[:]
+}
+
+// Matches reports whether secret has the given hash, in constant time.
+func Matches(secret string, hash []byte) bool {
+	return subtle.ConstantTimeCompare(Hash(secret), hash) == 1
+}
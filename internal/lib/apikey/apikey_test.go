@@ -0,0 +1,48 @@
+package apikey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hash, err := Generate()
+	require.NoError(t, err)
+
+	gotPrefix, secret, ok := Parse(key)
+	require.True(t, ok)
+	assert.Equal(t, prefix, gotPrefix)
+	assert.True(t, Matches(secret, hash))
+	assert.False(t, Matches(secret+"x", hash))
+
+	other, _, _, err := Generate()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		key    string
+		prefix string
+		secret string
+		ok     bool
+	}{
+		{key: "abc.def", prefix: "abc", secret: "def", ok: true},
+		{key: "abc.def.ghi", prefix: "abc", secret: "def.ghi", ok: true},
+		{key: "abcdef"},
+		{key: ".def"},
+		{key: "abc."},
+		{key: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			prefix, secret, ok := Parse(tt.key)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.prefix, prefix)
+			assert.Equal(t, tt.secret, secret)
+		})
+	}
+}
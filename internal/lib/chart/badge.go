@@ -0,0 +1,104 @@
+package chart
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+const (
+	badgeHeight  = 20
+	badgePadding = 6
+	labelColor   = "#555"
+	// BadgeColor is the default message background of a badge.
+	BadgeColor = "#007ec6"
+)
+
+// Badge draws a flat shields-style badge with label on the left and message
+// on a colored background on the right. Text widths are estimated from
+// Verdana metrics, since the SVG is rendered by the viewer.
+func Badge(w io.Writer, label, message, color string) error {
+	labelW := textWidth(label) + 2*badgePadding
+	messageW := textWidth(message) + 2*badgePadding
+	width := labelW + messageW
+
+	s := &svgWriter{w: w}
+	s.open(width, badgeHeight, label+": "+message)
+	s.printf(`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	s.printf(`<clipPath id="r"><rect width="%d" height="%d" rx="3" fill="#fff"/></clipPath>`, width, badgeHeight)
+	s.printf(`<g clip-path="url(#r)">`)
+	s.printf(`<rect width="%d" height="%d" fill="%s"/>`, labelW, badgeHeight, labelColor)
+	s.printf(`<rect x="%d" width="%d" height="%d" fill="%s"/>`, labelW, messageW, badgeHeight, escape(color))
+	s.printf(`<rect width="%d" height="%d" fill="url(#s)"/>`, width, badgeHeight)
+	s.printf(`</g>`)
+	s.printf(`<g fill="#fff" text-anchor="middle" font-family="%s" font-size="11">`, fontFamily)
+	for _, t := range []struct {
+		x    int
+		text string
+	}{{labelW / 2, label}, {labelW + messageW/2, message}} {
+		s.printf(`<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>`, t.x, escape(t.text))
+		s.printf(`<text x="%d" y="14">%s</text>`, t.x, escape(t.text))
+	}
+	s.printf(`</g>`)
+	s.close()
+
+	return s.err
+}
+
+// textWidth estimates the width of s in 11px Verdana.
+func textWidth(s string) int {
+	var width float64
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("iljI.,:;!|'", r):
+			width += 3.5
+		case strings.ContainsRune("frt()[] -", r):
+			width += 4.5
+		case strings.ContainsRune("mwMW", r):
+			width += 10.5
+		case unicode.IsUpper(r):
+			width += 8
+		default:
+			width += 7
+		}
+	}
+
+	return int(width + 0.5)
+}
+
+// Sparkline draws values as a bare line, scaled to the largest value, to be
+// shown inline with text.
+func Sparkline(w io.Writer, values []int64, width, height int) error {
+	var maxV int64
+	for _, v := range values {
+		maxV = max(maxV, v)
+	}
+
+	// keep the stroke inside the image
+	const inset = 1.5
+	plotW := float64(width) - 2*inset
+	plotH := float64(height) - 2*inset
+
+	s := &svgWriter{w: w}
+	s.open(width, height, "Clicks trend")
+	if len(values) > 0 {
+		line := make([]string, 0, len(values))
+		for i, v := range values {
+			x := inset + plotW/2
+			if len(values) > 1 {
+				x = inset + plotW*float64(i)/float64(len(values)-1)
+			}
+			y := inset + plotH
+			if maxV > 0 {
+				y -= plotH * float64(v) / float64(maxV)
+			}
+			line = append(line, coord(x)+","+coord(y))
+		}
+
+		s.printf(`<polyline fill="none" stroke="%s" stroke-width="1.5" stroke-linejoin="round" points="%s"/>`,
+			lineColor, strings.Join(line, " "))
+	}
+	s.close()
+
+	return s.err
+}
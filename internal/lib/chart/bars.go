@@ -0,0 +1,67 @@
+package chart
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	barHeight   = 14
+	barGap      = 6
+	barLabelW   = 150
+	barValueW   = 56
+	barMaxLabel = 24
+)
+
+// Bar is a labelled value of a bar chart.
+type Bar struct {
+	Label string
+	Value int64
+}
+
+// Bars draws one horizontal bar per entry, scaled to the largest value, with
+// its label on the left and its value on the right. Long labels are
+// shortened, with the full label in a tooltip.
+func Bars(w io.Writer, bars []Bar, width int) error {
+	var maxV int64
+	for _, b := range bars {
+		maxV = max(maxV, b.Value)
+	}
+
+	step := barHeight + barGap
+	height := max(len(bars)*step, step)
+	plotW := width - barLabelW - barValueW
+
+	s := &svgWriter{w: w}
+	s.open(width, height, "Clicks by value")
+	s.printf(`<g font-family="%s" font-size="11" fill="%s">`, fontFamily, textColor)
+	if len(bars) == 0 {
+		s.printf(`<text x="0" y="%d">No data</text>`, barHeight-3)
+	}
+	for i, b := range bars {
+		top := i * step
+		length := 0
+		if maxV > 0 {
+			length = max(int(float64(plotW)*float64(b.Value)/float64(maxV)), 1)
+		}
+
+		s.printf(`<g><title>%s</title>`, escape(fmt.Sprintf("%s: %d clicks", b.Label, b.Value)))
+		s.printf(`<text x="0" y="%d">%s</text>`, top+barHeight-3, escape(shorten(b.Label, barMaxLabel)))
+		s.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"/>`, barLabelW, top, length, barHeight, lineColor)
+		s.printf(`<text x="%d" y="%d">%d</text>`, barLabelW+length+4, top+barHeight-3, b.Value)
+		s.printf(`</g>`)
+	}
+	s.printf(`</g>`)
+	s.close()
+
+	return s.err
+}
+
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,54 @@
+// Package chart draws small self-contained SVG charts.
+package chart
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+const (
+	fontFamily = "Verdana,DejaVu Sans,sans-serif"
+	emptyColor = "#ebedf0"
+	textColor  = "#57606a"
+)
+
+// scale maps 0..1 onto a green palette, with 0 being the empty color.
+var scale = []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+func shade(v, maxV int64) string {
+	if v <= 0 || maxV <= 0 {
+		return emptyColor
+	}
+
+	i := 1 + int(float64(v)/float64(maxV)*float64(len(scale)-2)+0.5)
+
+	return scale[min(i, len(scale)-1)]
+}
+
+type svgWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (s *svgWriter) printf(format string, args ...any) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, format, args...)
+}
+
+func (s *svgWriter) open(width, height int, label string) {
+	s.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="%s">`,
+		width, height, width, height, escape(label))
+	s.printf(`<title>%s</title>`, escape(label))
+}
+
+func (s *svgWriter) close() {
+	s.printf(`</svg>`)
+}
+
+func escape(s string) string {
+	return html.EscapeString(strings.ToValidUTF8(s, ""))
+}
@@ -0,0 +1,53 @@
+package chart
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	cellSize   = 16
+	cellGap    = 2
+	heatLeft   = 36
+	heatTop    = 18
+	heatBottom = 4
+)
+
+var weekdays = [7]string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// Heatmap draws clicks by day of week (rows, starting with Monday) and hour
+// of day (columns). Each cell has a tooltip with its exact count.
+func Heatmap(w io.Writer, cells [7][24]int64) error {
+	var maxV int64
+	for _, row := range cells {
+		for _, v := range row {
+			maxV = max(maxV, v)
+		}
+	}
+
+	step := cellSize + cellGap
+	width := heatLeft + 24*step
+	height := heatTop + 7*step + heatBottom
+
+	s := &svgWriter{w: w}
+	s.open(width, height, "Clicks by day of week and hour")
+	s.printf(`<g font-family="%s" font-size="10" fill="%s">`, fontFamily, textColor)
+	for h := 0; h < 24; h += 3 {
+		s.printf(`<text x="%d" y="%d">%02d</text>`, heatLeft+h*step, heatTop-6, h)
+	}
+	for d, name := range weekdays {
+		s.printf(`<text x="0" y="%d">%s</text>`, heatTop+d*step+cellSize-4, name)
+	}
+	s.printf(`</g>`)
+
+	for d, row := range cells {
+		for h, v := range row {
+			s.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s</title></rect>`,
+				heatLeft+h*step, heatTop+d*step, cellSize, cellSize, shade(v, maxV),
+				fmt.Sprintf("%s %02d:00: %d clicks", weekdays[d], h, v))
+		}
+	}
+	s.close()
+
+	return s.err
+}
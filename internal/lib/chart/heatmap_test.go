@@ -0,0 +1,57 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// elements parses svg and counts its elements by name.
+func elements(t *testing.T, svg string) map[string]int {
+	t.Helper()
+
+	counts := make(map[string]int)
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return counts
+		}
+		require.NoError(t, err)
+
+		if se, ok := tok.(xml.StartElement); ok {
+			counts[se.Name.Local]++
+		}
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	var cells [7][24]int64
+	cells[0][9] = 4
+	cells[6][23] = 1
+
+	var buf bytes.Buffer
+	require.NoError(t, Heatmap(&buf, cells))
+
+	svg := buf.String()
+	counts := elements(t, svg)
+	assert.Equal(t, 1, counts["svg"])
+	assert.Equal(t, 7*24, counts["rect"])
+
+	assert.Contains(t, svg, `fill="#216e39"><title>Mon 09:00: 4 clicks</title>`)
+	assert.Contains(t, svg, `<title>Sun 23:00: 1 clicks</title>`)
+	assert.Contains(t, svg, `fill="#ebedf0"><title>Tue 00:00: 0 clicks</title>`)
+}
+
+func TestShade(t *testing.T) {
+	assert.Equal(t, emptyColor, shade(0, 10))
+	assert.Equal(t, emptyColor, shade(0, 0))
+	assert.Equal(t, scale[1], shade(1, 100))
+	assert.Equal(t, scale[len(scale)-1], shade(10, 10))
+}
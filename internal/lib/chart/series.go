@@ -0,0 +1,98 @@
+package chart
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	seriesLeft   = 44
+	seriesRight  = 8
+	seriesTop    = 8
+	seriesBottom = 18
+	lineColor    = "#30a14e"
+	areaColor    = "#9be9a8"
+	gridColor    = "#d0d7de"
+	// maxTooltips bounds the hover targets of long series.
+	maxTooltips = 500
+)
+
+// Point is a single value of a time series.
+type Point struct {
+	Label string
+	Value int64
+}
+
+// Series draws points as an area chart, with the largest value on the y axis
+// and the first and last labels on the x axis.
+func Series(w io.Writer, points []Point, width, height int) error {
+	var maxV int64
+	for _, p := range points {
+		maxV = max(maxV, p.Value)
+	}
+
+	plotW := float64(width - seriesLeft - seriesRight)
+	plotH := float64(height - seriesTop - seriesBottom)
+	x := func(i int) float64 {
+		if len(points) < 2 {
+			return seriesLeft + plotW/2
+		}
+		return seriesLeft + plotW*float64(i)/float64(len(points)-1)
+	}
+	y := func(v int64) float64 {
+		if maxV == 0 {
+			return seriesTop + plotH
+		}
+		return seriesTop + plotH - plotH*float64(v)/float64(maxV)
+	}
+
+	s := &svgWriter{w: w}
+	s.open(width, height, "Clicks over time")
+
+	bottom := seriesTop + plotH
+	s.printf(`<g stroke="%s" stroke-width="1">`, gridColor)
+	s.printf(`<line x1="%d" y1="%d" x2="%d" y2="%d"/>`, seriesLeft, seriesTop, width-seriesRight, seriesTop)
+	s.printf(`<line x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, seriesLeft, bottom, width-seriesRight, bottom)
+	s.printf(`</g>`)
+
+	s.printf(`<g font-family="%s" font-size="10" fill="%s">`, fontFamily, textColor)
+	s.printf(`<text x="%d" y="%d" text-anchor="end">%d</text>`, seriesLeft-4, seriesTop+4, maxV)
+	s.printf(`<text x="%d" y="%.1f" text-anchor="end">0</text>`, seriesLeft-4, bottom+3)
+	if len(points) > 0 {
+		s.printf(`<text x="%d" y="%d">%s</text>`, seriesLeft, height-4, escape(points[0].Label))
+		s.printf(`<text x="%d" y="%d" text-anchor="end">%s</text>`, width-seriesRight, height-4, escape(points[len(points)-1].Label))
+	}
+	s.printf(`</g>`)
+
+	if len(points) > 0 {
+		line := make([]string, 0, len(points))
+		for i, p := range points {
+			line = append(line, coord(x(i))+","+coord(y(p.Value)))
+		}
+
+		s.printf(`<polygon fill="%s" fill-opacity="0.5" points="%s,%s %s %s,%s"/>`, areaColor,
+			coord(x(0)), coord(bottom), strings.Join(line, " "), coord(x(len(points)-1)), coord(bottom))
+		s.printf(`<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, lineColor, strings.Join(line, " "))
+	}
+
+	if len(points) <= maxTooltips {
+		// each point is hovered from halfway to its neighbours
+		slot := plotW
+		if len(points) > 1 {
+			slot = plotW / float64(len(points)-1)
+		}
+		for i, p := range points {
+			s.printf(`<rect x="%s" y="%d" width="%s" height="%s" fill="transparent"><title>%s</title></rect>`,
+				coord(x(i)-slot/2), seriesTop, coord(slot), coord(plotH), escape(fmt.Sprintf("%s: %d clicks", p.Label, p.Value)))
+		}
+	}
+	s.close()
+
+	return s.err
+}
+
+func coord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
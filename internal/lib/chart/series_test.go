@@ -0,0 +1,89 @@
+package chart
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeries(t *testing.T) {
+	points := []Point{
+		{Label: "Aug 10", Value: 0},
+		{Label: "Aug 11", Value: 8},
+		{Label: "Aug 12", Value: 4},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Series(&buf, points, 400, 120))
+
+	svg := buf.String()
+	counts := elements(t, svg)
+	assert.Equal(t, 1, counts["polyline"])
+	assert.Equal(t, 1, counts["polygon"])
+	assert.Equal(t, 3, counts["rect"])
+
+	assert.Contains(t, svg, `>8</text>`)
+	assert.Contains(t, svg, `>Aug 10</text>`)
+	assert.Contains(t, svg, `>Aug 12</text>`)
+	assert.Contains(t, svg, `<title>Aug 11: 8 clicks</title>`)
+	// the peak touches the top of the plot
+	assert.Contains(t, svg, `218.0,8.0`)
+}
+
+func TestSeries_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Series(&buf, nil, 400, 120))
+
+	counts := elements(t, buf.String())
+	assert.Zero(t, counts["polyline"])
+}
+
+func TestBars(t *testing.T) {
+	bars := []Bar{
+		{Label: "google.com", Value: 10},
+		{Label: "a-very-long-referrer-domain.example.com", Value: 5},
+		{Label: "<script>", Value: 0},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Bars(&buf, bars, 400))
+
+	svg := buf.String()
+	counts := elements(t, svg)
+	assert.Equal(t, 3, counts["rect"])
+
+	assert.Contains(t, svg, `>google.com</text>`)
+	assert.Contains(t, svg, `>a-very-long-referrer-do…</text>`)
+	assert.Contains(t, svg, `<title>a-very-long-referrer-domain.example.com: 5 clicks</title>`)
+	assert.Contains(t, svg, `&lt;script&gt;`)
+	assert.False(t, strings.Contains(svg, "<script>"))
+	assert.Contains(t, svg, `width="194" height="14"`)
+}
+
+func TestBars_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Bars(&buf, nil, 400))
+
+	assert.Contains(t, buf.String(), "No data")
+}
+
+func TestBadge(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Badge(&buf, "clicks", "1.2k", BadgeColor))
+
+	svg := buf.String()
+	assert.Equal(t, 4, elements(t, svg)["rect"])
+	assert.Contains(t, svg, `aria-label="clicks: 1.2k"`)
+	assert.Contains(t, svg, `>1.2k</text>`)
+	assert.Contains(t, svg, `fill="#007ec6"`)
+}
+
+func TestSparkline(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Sparkline(&buf, []int64{0, 4, 2}, 103, 23))
+
+	assert.Contains(t, buf.String(), `points="1.5,21.5 51.5,1.5 101.5,11.5"`)
+}
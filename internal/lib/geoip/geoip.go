@@ -0,0 +1,61 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+var ErrInvalidIP = errors.New("invalid ip address")
+
+type Location struct {
+	Country string // ISO 3166-1 alpha-2 code
+	Region  string // first-level subdivision, e.g. state or oblast
+	City    string
+}
+
+// Reader looks up client locations in a local MaxMind-format (.mmdb) city database.
+type Reader struct {
+	db *geoip2.Reader
+}
+
+func Open(path string) (*Reader, error) {
+	const op = "geoip.Open"
+
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Reader{db: db}, nil
+}
+
+func (r *Reader) Lookup(ip string) (Location, error) {
+	const op = "geoip.Lookup"
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}, fmt.Errorf("%s: %w: %q", op, ErrInvalidIP, ip)
+	}
+
+	record, err := r.db.City(parsed)
+	if err != nil {
+		return Location{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	loc := Location{
+		Country: record.Country.IsoCode,
+		City:    record.City.Names["en"],
+	}
+	if len(record.Subdivisions) > 0 {
+		loc.Region = record.Subdivisions[0].Names["en"]
+	}
+
+	return loc, nil
+}
+
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
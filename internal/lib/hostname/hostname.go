@@ -0,0 +1,17 @@
+package hostname
+
+import (
+	"net"
+	"strings"
+)
+
+// Normalize lowercases host and strips its port and trailing dot, so that
+// "Brand.example:443" and "brand.example." both become "brand.example".
+func Normalize(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,28 @@
+package hostname
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "brand.example", want: "brand.example"},
+		{host: "Brand.Example", want: "brand.example"},
+		{host: "brand.example:8082", want: "brand.example"},
+		{host: "brand.example.", want: "brand.example"},
+		{host: " brand.example ", want: "brand.example"},
+		{host: "[::1]:8082", want: "::1"},
+		{host: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.host))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package qrcode draws QR codes as PNG or SVG images.
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+
+	qr "github.com/skip2/go-qrcode"
+)
+
+// Level is an error correction level: the share of the code that can be
+// damaged and still be read.
+type Level string
+
+const (
+	LevelLow      Level = "L" // 7%
+	LevelMedium   Level = "M" // 15%
+	LevelQuartile Level = "Q" // 25%
+	LevelHigh     Level = "H" // 30%
+)
+
+var levels = map[Level]qr.RecoveryLevel{
+	LevelLow:      qr.Low,
+	LevelMedium:   qr.Medium,
+	LevelQuartile: qr.High,
+	LevelHigh:     qr.Highest,
+}
+
+// ParseLevel returns the level named by s, case-insensitively.
+func ParseLevel(s string) (Level, error) {
+	l := Level(strings.ToUpper(s))
+	if _, ok := levels[l]; !ok {
+		return "", fmt.Errorf("invalid error correction level %q", s)
+	}
+
+	return l, nil
+}
+
+// Options describe the image of a code.
+type Options struct {
+	// Size is the width and height of the image in pixels. Modules are
+	// scaled by a whole number of pixels, so the rest goes to the margin.
+	Size  int
+	Level Level
+	// Margin is the quiet zone around the code in modules; scanners expect 4.
+	Margin     int
+	Foreground color.Color
+	Background color.Color
+}
+
+// Code is an encoded QR code.
+type Code struct {
+	modules [][]bool
+	opts    Options
+}
+
+// New encodes content with opts.
+func New(content string, opts Options) (*Code, error) {
+	level, ok := levels[opts.Level]
+	if !ok {
+		return nil, fmt.Errorf("invalid error correction level %q", opts.Level)
+	}
+
+	q, err := qr.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode qr code: %w", err)
+	}
+	q.DisableBorder = true
+
+	return &Code{modules: q.Bitmap(), opts: opts}, nil
+}
+
+// layout returns the size of a module and the offset of the first one in
+// pixels.
+func (c *Code) layout() (scale, offset int) {
+	n := len(c.modules)
+	scale = max(c.opts.Size/(n+2*c.opts.Margin), 1)
+
+	return scale, (c.size(scale) - n*scale) / 2
+}
+
+func (c *Code) size(scale int) int {
+	return max(c.opts.Size, (len(c.modules)+2*c.opts.Margin)*scale)
+}
+
+// PNG writes the code as a two-color PNG image.
+func (c *Code) PNG(w io.Writer) error {
+	scale, offset := c.layout()
+	size := c.size(scale)
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{c.opts.Background, c.opts.Foreground})
+	for y, row := range c.modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for py := offset + y*scale; py < offset+(y+1)*scale; py++ {
+				for px := offset + x*scale; px < offset+(x+1)*scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// SVG writes the code as an SVG image with a single path for the dark
+// modules.
+func (c *Code) SVG(w io.Writer) error {
+	scale, offset := c.layout()
+	size := c.size(scale)
+
+	var path strings.Builder
+	for y, row := range c.modules {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			// merge horizontal runs to keep the path short
+			start := x
+			for x+1 < len(row) && row[x+1] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv%dh-%dz", offset+start*scale, offset+y*scale, (x-start+1)*scale, scale, (x-start+1)*scale)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="%s"/><path fill="%s" d="%s"/></svg>`,
+		size, size, size, size, Hex(c.opts.Background), Hex(c.opts.Foreground), path.String())
+
+	return err
+}
+
+// ParseColor parses a hex color such as "000", "#1f2328" or "1f232880",
+// with an optional alpha channel.
+func ParseColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	var r, g, b, a uint8
+	if len(hex) != 8 {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x%02x", &r, &g, &b, &a); err != nil {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+
+	return color.NRGBA{R: r, G: g, B: b, A: a}, nil
+}
+
+// Hex formats c as a CSS hex color.
+func Hex(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
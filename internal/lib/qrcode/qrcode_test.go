@@ -0,0 +1,95 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	black = color.NRGBA{A: 0xff}
+	white = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+func TestPNG(t *testing.T) {
+	code, err := New("https://sho.rt/s/promo?src=qr", Options{Size: 256, Level: LevelMedium, Margin: 4, Foreground: black, Background: white})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, code.PNG(&buf))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, 256, img.Bounds().Dx())
+	assert.Equal(t, 256, img.Bounds().Dy())
+	assert.Equal(t, white, color.NRGBAModel.Convert(img.At(0, 0)))
+
+	// modules are scaled by whole pixels, the rest is added to the margin
+	n := len(code.modules)
+	scale := 256 / (n + 8)
+	offset := (256 - n*scale) / 2
+	assert.Equal(t, black, color.NRGBAModel.Convert(img.At(offset, offset)), "finder pattern")
+	assert.Equal(t, white, color.NRGBAModel.Convert(img.At(offset-1, offset)))
+}
+
+func TestPNG_SmallerThanCode(t *testing.T) {
+	code, err := New("https://sho.rt/s/promo", Options{Size: 10, Level: LevelHigh, Margin: 0, Foreground: black, Background: white})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, code.PNG(&buf))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, len(code.modules), img.Bounds().Dx())
+}
+
+func TestSVG(t *testing.T) {
+	fg, err := ParseColor("#1f2328")
+	require.NoError(t, err)
+
+	code, err := New("https://sho.rt/s/promo", Options{Size: 100, Level: LevelLow, Margin: 2, Foreground: fg, Background: white})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, code.SVG(&buf))
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100"`))
+	assert.Contains(t, svg, `<rect width="100%" height="100%" fill="#ffffff"/>`)
+	assert.Contains(t, svg, `<path fill="#1f2328" d="M`)
+}
+
+func TestParseColor(t *testing.T) {
+	tests := map[string]color.Color{
+		"000":       black,
+		"#FFF":      white,
+		"1f2328":    color.NRGBA{R: 0x1f, G: 0x23, B: 0x28, A: 0xff},
+		"#00000000": color.NRGBA{},
+	}
+	for s, expected := range tests {
+		c, err := ParseColor(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, c, s)
+	}
+
+	for _, s := range []string{"", "red", "#12345", "#gggggg"} {
+		_, err := ParseColor(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	l, err := ParseLevel("q")
+	require.NoError(t, err)
+	assert.Equal(t, LevelQuartile, l)
+
+	_, err = ParseLevel("X")
+	assert.Error(t, err)
+}
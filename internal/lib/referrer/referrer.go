@@ -0,0 +1,49 @@
+package referrer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const maxSourceLength = 64
+
+// Domain normalizes a Referer header value to its host: lowercased, without
+// port and leading "www.". Empty or unparsable values yield "".
+func Domain(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		// some clients send a bare host without a scheme
+		u, err = url.Parse("//" + raw)
+		if err != nil {
+			return ""
+		}
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+
+	return strings.TrimPrefix(host, "www.")
+}
+
+// Source returns the campaign source of a short link request, taken from the
+// "src" query parameter or, if absent, from "utm_source".
+func Source(r *http.Request) string {
+	q := r.URL.Query()
+
+	src := q.Get("src")
+	if src == "" {
+		src = q.Get("utm_source")
+	}
+
+	src = strings.ToLower(strings.TrimSpace(src))
+	if len(src) > maxSourceLength {
+		src = src[:maxSourceLength]
+	}
+
+	return src
+}
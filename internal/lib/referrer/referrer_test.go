@@ -0,0 +1,47 @@
+package referrer
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "empty", raw: "", want: ""},
+		{name: "full url", raw: "https://www.Google.com/search?q=go", want: "google.com"},
+		{name: "with port", raw: "http://news.ycombinator.com:8080/item", want: "news.ycombinator.com"},
+		{name: "bare host", raw: "t.co", want: "t.co"},
+		{name: "trailing dot", raw: "https://example.org./", want: "example.org"},
+		{name: "garbage", raw: "%%%", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Domain(tt.raw))
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{name: "none", target: "/s/abc", want: ""},
+		{name: "src", target: "/s/abc?src=Newsletter", want: "newsletter"},
+		{name: "utm_source", target: "/s/abc?utm_source=twitter", want: "twitter"},
+		{name: "src wins", target: "/s/abc?src=qr&utm_source=twitter", want: "qr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.target, nil)
+			assert.Equal(t, tt.want, Source(r))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package shortlink
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PreviewSuffix appended to a short link shows where it goes instead of
+// following it, e.g. /s/promo+.
+const PreviewSuffix = "+"
+
+// Builder makes public short URLs out of aliases.
+type Builder struct {
+	baseURL  string
+	scheme   string
+	prefix   string
+	reserved map[string]struct{}
+}
+
+// New returns a Builder for short links served under prefix. An empty
+// baseURL makes short URLs use the scheme and host of the request.
+func New(baseURL, prefix string) (*Builder, error) {
+	var scheme string
+	if baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public base url: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid public base url %q: scheme and host are required", baseURL)
+		}
+		scheme = u.Scheme
+	}
+
+	return &Builder{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		scheme:   scheme,
+		prefix:   "/" + strings.Trim(prefix, "/") + "/",
+		reserved: make(map[string]struct{}),
+	}, nil
+}
+
+// Prefix returns the path prefix of short links, with leading and trailing
+// slashes, e.g. "/s/". It is "/" for root-level links.
+func (b *Builder) Prefix() string {
+	if b.prefix == "//" {
+		return "/"
+	}
+	return b.prefix
+}
+
+// Root reports whether short links are served at the root of the host.
+func (b *Builder) Root() bool {
+	return b.Prefix() == "/"
+}
+
+// Reserve makes names unavailable as root-level aliases, so that links can't
+// shadow routes or static files. It must be called before serving requests.
+func (b *Builder) Reserve(names ...string) {
+	for _, name := range names {
+		if name = strings.Trim(name, "/"); name != "" {
+			b.reserved[name] = struct{}{}
+		}
+	}
+}
+
+// IsReserved reports whether alias is reserved. Aliases under a prefix never
+// are, since they can't collide with other paths.
+func (b *Builder) IsReserved(alias string) bool {
+	if !b.Root() {
+		return false
+	}
+
+	_, ok := b.reserved[alias]
+	return ok
+}
+
+// BaseURL returns the public base URL of r without a trailing slash.
+func (b *Builder) BaseURL(r *http.Request) string {
+	if b.baseURL != "" {
+		return b.baseURL
+	}
+
+	return b.schemeOf(r) + "://" + r.Host
+}
+
+// URL returns the public short URL of alias on domain. An empty domain is the
+// default one, served at the base URL; custom domains are served at their
+// root with the scheme of the base URL.
+func (b *Builder) URL(r *http.Request, domain, alias string) string {
+	base := b.BaseURL(r)
+	if domain != "" {
+		base = b.schemeOf(r) + "://" + domain
+	}
+
+	return base + b.Prefix() + url.PathEscape(alias)
+}
+
+func (b *Builder) schemeOf(r *http.Request) string {
+	switch {
+	case b.scheme != "":
+		return b.scheme
+	case r.TLS != nil:
+		return "https"
+	default:
+		return "http"
+	}
+}
@@ -0,0 +1,119 @@
+package shortlink
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_URL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		prefix  string
+		tls     bool
+		domain  string
+		alias   string
+		want    string
+	}{
+		{
+			name:    "Configured base URL",
+			baseURL: "https://sho.rt/",
+			prefix:  "/s/",
+			alias:   "abc",
+			want:    "https://sho.rt/s/abc",
+		},
+		{
+			name:    "Base URL with path",
+			baseURL: "https://example.com/links",
+			prefix:  "go",
+			alias:   "abc",
+			want:    "https://example.com/links/go/abc",
+		},
+		{
+			name:   "Request host",
+			prefix: "/s/",
+			alias:  "abc",
+			want:   "http://example.com/s/abc",
+		},
+		{
+			name:   "Request over TLS",
+			prefix: "/s",
+			tls:    true,
+			alias:  "abc",
+			want:   "https://example.com/s/abc",
+		},
+		{
+			name:    "Root prefix",
+			baseURL: "https://sho.rt",
+			prefix:  "/",
+			alias:   "abc",
+			want:    "https://sho.rt/abc",
+		},
+		{
+			name:    "Custom domain",
+			baseURL: "https://sho.rt/links",
+			prefix:  "/s/",
+			domain:  "brand.example",
+			alias:   "abc",
+			want:    "https://brand.example/s/abc",
+		},
+		{
+			name:   "Custom domain over TLS",
+			prefix: "/s/",
+			tls:    true,
+			domain: "brand.example",
+			alias:  "abc",
+			want:   "https://brand.example/s/abc",
+		},
+		{
+			name:    "Escaped alias",
+			baseURL: "https://sho.rt",
+			prefix:  "/s/",
+			alias:   "a b",
+			want:    "https://sho.rt/s/a%20b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := New(tt.baseURL, tt.prefix)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodPost, "/shorten", nil)
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+
+			assert.Equal(t, tt.want, b.URL(r, tt.domain, tt.alias))
+		})
+	}
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"sho.rt", "://sho.rt", "/s"} {
+		_, err := New(baseURL, "/s/")
+		assert.Error(t, err, baseURL)
+	}
+}
+
+func TestBuilder_IsReserved(t *testing.T) {
+	root, err := New("", "/")
+	require.NoError(t, err)
+	root.Reserve("stats", "/admin/", "")
+
+	assert.True(t, root.IsReserved("stats"))
+	assert.True(t, root.IsReserved("admin"))
+	assert.False(t, root.IsReserved(""))
+	assert.False(t, root.IsReserved("promo"))
+
+	prefixed, err := New("", "/s/")
+	require.NoError(t, err)
+	prefixed.Reserve("stats")
+
+	assert.False(t, prefixed.IsReserved("stats"))
+}
@@ -0,0 +1,84 @@
+package timeseries
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Granularity string
+
+const (
+	Hour  Granularity = "hour"
+	Day   Granularity = "day"
+	Week  Granularity = "week"
+	Month Granularity = "month"
+)
+
+var ErrInvalidGranularity = errors.New("invalid granularity")
+
+func ParseGranularity(s string) (Granularity, error) {
+	switch g := Granularity(s); g {
+	case Hour, Day, Week, Month:
+		return g, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidGranularity, s)
+	}
+}
+
+// Truncate returns the start of the bucket containing t in loc. Weeks start
+// on Monday, matching Postgres date_trunc.
+func Truncate(t time.Time, g Granularity, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+
+	switch g {
+	case Hour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+}
+
+// Next returns the start of the bucket following the one starting at t.
+func Next(t time.Time, g Granularity, loc *time.Location) time.Time {
+	t = t.In(loc)
+
+	switch g {
+	case Hour:
+		return t.Add(time.Hour)
+	case Week:
+		return Truncate(t.AddDate(0, 0, 7), g, loc)
+	case Month:
+		return Truncate(t.AddDate(0, 1, 0), g, loc)
+	default:
+		return Truncate(t.AddDate(0, 0, 1), g, loc)
+	}
+}
+
+// Buckets returns the starts of all buckets overlapping [from, to), in order.
+func Buckets(from, to time.Time, g Granularity, loc *time.Location) []time.Time {
+	var buckets []time.Time
+	for t := Truncate(from, g, loc); t.Before(to); t = Next(t, g, loc) {
+		buckets = append(buckets, t)
+	}
+
+	return buckets
+}
+
+// Count returns the number of buckets overlapping [from, to) without
+// allocating them. It stops at limit+1, so that callers can reject oversized
+// ranges without walking them.
+func Count(from, to time.Time, g Granularity, loc *time.Location, limit int) int {
+	n := 0
+	for t := Truncate(from, g, loc); t.Before(to) && n <= limit; t = Next(t, g, loc) {
+		n++
+	}
+
+	return n
+}
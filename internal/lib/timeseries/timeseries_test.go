@@ -0,0 +1,81 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	// 2025-08-13 is a Wednesday; 22:30 UTC is already Thursday in Moscow
+	ts := time.Date(2025, 8, 13, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		g    Granularity
+		loc  *time.Location
+		want time.Time
+	}{
+		{name: "hour", g: Hour, loc: time.UTC, want: time.Date(2025, 8, 13, 22, 0, 0, 0, time.UTC)},
+		{name: "day utc", g: Day, loc: time.UTC, want: time.Date(2025, 8, 13, 0, 0, 0, 0, time.UTC)},
+		{name: "day moscow", g: Day, loc: moscow, want: time.Date(2025, 8, 14, 0, 0, 0, 0, moscow)},
+		{name: "week", g: Week, loc: time.UTC, want: time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "month", g: Month, loc: moscow, want: time.Date(2025, 8, 1, 0, 0, 0, 0, moscow)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(Truncate(ts, tt.g, tt.loc)), Truncate(ts, tt.g, tt.loc))
+		})
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	from := time.Date(2025, 1, 30, 12, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	got := Buckets(from, to, Month, time.UTC)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}, got)
+	assert.Equal(t, len(got), Count(from, to, Month, time.UTC, 100))
+}
+
+func TestCount_Limit(t *testing.T) {
+	from := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 2001, Count(from, to, Hour, time.UTC, 2000))
+	assert.Equal(t, 2, Count(from, from.Add(90*time.Minute), Hour, time.UTC, 2000))
+}
+
+func TestBuckets_DST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// clocks go forward on 2025-03-30, that day is 23 hours long
+	from := time.Date(2025, 3, 29, 0, 0, 0, 0, berlin)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, berlin)
+
+	got := Buckets(from, to, Day, berlin)
+
+	require.Len(t, got, 3)
+	assert.Equal(t, 23*time.Hour, got[2].Sub(got[1]))
+	assert.Equal(t, 72-1, int(to.Sub(from).Hours()))
+}
+
+func TestParseGranularity(t *testing.T) {
+	g, err := ParseGranularity("week")
+	assert.NoError(t, err)
+	assert.Equal(t, Week, g)
+
+	_, err = ParseGranularity("year")
+	assert.ErrorIs(t, err, ErrInvalidGranularity)
+}
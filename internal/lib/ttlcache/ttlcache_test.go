@@ -0,0 +1,60 @@
+package ttlcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Expiry(t *testing.T) {
+	now := time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC)
+
+	c := New[string, int](time.Minute, 10)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+}
+
+func TestCache_Eviction(t *testing.T) {
+	now := time.Date(2025, 8, 11, 10, 0, 0, 0, time.UTC)
+
+	c := New[string, int](time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	c.Set("old", 1)
+	now = now.Add(30 * time.Second)
+	c.Set("a", 2)
+	now = now.Add(40 * time.Second)
+
+	// "old" has expired, so it makes room for "b" and "a" stays
+	c.Set("b", 3)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("old")
+	assert.False(t, ok)
+
+	c.Set("c", 4)
+	assert.Len(t, c.entries, 2)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+}
+
+func TestCache_Disabled(t *testing.T) {
+	c := New[string, int](0, 10)
+	c.Set("a", 1)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,45 @@
+package useragent
+
+import "strings"
+
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+var (
+	botTokens    = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client", "headless", "preview"}
+	tabletTokens = []string{"ipad", "tablet", "kindle", "silk/", "playbook"}
+	mobileTokens = []string{"mobile", "iphone", "ipod", "android", "windows phone", "opera mini", "blackberry"}
+)
+
+// Device classifies a User-Agent header by the kind of client that sent it.
+// It is a keyword heuristic meant for dashboards, not exact detection.
+func Device(ua string) string {
+	ua = strings.ToLower(strings.TrimSpace(ua))
+	switch {
+	case ua == "":
+		return DeviceUnknown
+	case containsAny(ua, botTokens):
+		return DeviceBot
+	case containsAny(ua, tabletTokens),
+		strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return DeviceTablet
+	case containsAny(ua, mobileTokens):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+func containsAny(s string, tokens []string) bool {
+	for _, t := range tokens {
+		if strings.Contains(s, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevice(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want string
+	}{
+		{ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36", want: DeviceDesktop},
+		{ua: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15", want: DeviceDesktop},
+		{ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", want: DeviceMobile},
+		{ua: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/126.0 Mobile Safari/537.36", want: DeviceMobile},
+		{ua: "Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", want: DeviceTablet},
+		{ua: "Mozilla/5.0 (Linux; Android 13; SM-X200) AppleWebKit/537.36 Chrome/126.0 Safari/537.36", want: DeviceTablet},
+		{ua: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", want: DeviceBot},
+		{ua: "curl/8.5.0", want: DeviceBot},
+		{ua: "", want: DeviceUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.ua, func(t *testing.T) {
+			assert.Equal(t, tt.want, Device(tt.ua))
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package metrics collects the service's Prometheus metrics.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "url_shortener"
+
+// ClickQueue is the part of clicks.Tracker exported as metrics.
+type ClickQueue interface {
+	Saturation() float64
+	Failures() int64
+}
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	redirects       *prometheus.CounterVec
+	analyticsFailed prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_call_duration_seconds",
+			Help:      "Latency of storage calls by method.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 10},
+		}, []string{"method"}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redirects_total",
+			Help:      "Short link lookups by result: hit or not_found.",
+		}, []string{"result"}),
+		analyticsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redirect_analytics_failures_total",
+			Help:      "Redirects whose click couldn't be queued for saving.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.redirects,
+		m.analyticsFailed,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterClickQueue exports the saturation and save failures of q.
+func (m *Metrics) RegisterClickQueue(q ClickQueue) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_queue_saturation",
+			Help:      "Share of the click queue in use, from 0 to 1.",
+		}, q.Saturation),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_save_failures_total",
+			Help:      "Queued clicks that couldn't be saved.",
+		}, func() float64 { return float64(q.Failures()) }),
+	)
+}
+
+func (m *Metrics) ObserveRequest(route, method string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(route, method, code).Inc()
+	m.requestDuration.WithLabelValues(route, method, code).Observe(d.Seconds())
+}
+
+func (m *Metrics) ObserveQuery(method string, d time.Duration) {
+	m.queryDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+func (m *Metrics) RedirectHit() {
+	m.redirects.WithLabelValues("hit").Inc()
+}
+
+func (m *Metrics) RedirectNotFound() {
+	m.redirects.WithLabelValues("not_found").Inc()
+}
+
+func (m *Metrics) AnalyticsWriteFailed() {
+	m.analyticsFailed.Inc()
+}
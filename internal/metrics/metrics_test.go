@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueue struct{}
+
+func (fakeQueue) Saturation() float64 { return 0.25 }
+func (fakeQueue) Failures() int64     { return 3 }
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.RegisterClickQueue(fakeQueue{})
+
+	m.ObserveRequest("/s/{short_url}", http.MethodGet, http.StatusFound, 5*time.Millisecond)
+	m.ObserveQuery("GetURL", time.Millisecond)
+	m.RedirectHit()
+	m.RedirectHit()
+	m.RedirectNotFound()
+	m.AnalyticsWriteFailed()
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		`url_shortener_http_requests_total{method="GET",route="/s/{short_url}",status="302"} 1`,
+		`url_shortener_http_request_duration_seconds_count{method="GET",route="/s/{short_url}",status="302"} 1`,
+		`url_shortener_storage_call_duration_seconds_count{method="GetURL"} 1`,
+		`url_shortener_redirects_total{result="hit"} 2`,
+		`url_shortener_redirects_total{result="not_found"} 1`,
+		`url_shortener_redirect_analytics_failures_total 1`,
+		`url_shortener_click_queue_saturation 0.25`,
+		`url_shortener_click_save_failures_total 3`,
+	} {
+		assert.Contains(t, string(body), line)
+	}
+}
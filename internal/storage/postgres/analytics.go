@@ -0,0 +1,373 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/lib/timeseries"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const topLimit = 10
+
+// clickSource splits an analytics query between a rollup table, which covers
+// [from, to), and raw url_analytics rows, which cover the rest of the query
+// range: a partial bucket at the start and everything not yet rolled up.
+// In zones whose offset isn't a whole number of hours, UTC hours don't line
+// up with the query buckets, and a rolled-up hour counts towards the bucket
+// it starts in; totals of the range are still exact.
+type clickSource struct {
+	table rollupTable
+	from  time.Time
+	to    time.Time
+}
+
+func (s *Storage) clickSource(ctx context.Context, q storage.AnalyticsQuery) (clickSource, error) {
+	t := clickTable(q)
+
+	watermark, err := s.watermark(ctx, t)
+	if err != nil {
+		return clickSource{}, err
+	}
+
+	return sourceFor(q, t, watermark), nil
+}
+
+// sources returns the clickSource of q and the source of its unique visitors,
+// which splits q between url_visitors_hourly and raw rows. Both watermarks
+// are read in one query. The visitor rollup is written along with the hourly
+// one, so it shares its range.
+func (s *Storage) sources(ctx context.Context, q storage.AnalyticsQuery) (clicks, visitors clickSource, err error) {
+	t := clickTable(q)
+
+	var clickMark, visitorMark sql.NullTime
+	err = s.db.QueryRowContext(ctx, `SELECT
+		(SELECT rolled_until FROM rollup_state WHERE name = $1),
+		(SELECT rolled_until FROM rollup_state WHERE name = $2)`, t.name, hourlyRollup.name).
+		Scan(&clickMark, &visitorMark)
+	if err != nil {
+		return clickSource{}, clickSource{}, fmt.Errorf("couldn't get watermarks: %w", err)
+	}
+
+	return sourceFor(q, t, clickMark.Time), sourceFor(q, hourlyRollup, visitorMark.Time), nil
+}
+
+func clickTable(q storage.AnalyticsQuery) rollupTable {
+	if q.Granularity != timeseries.Hour && q.Location.String() == "UTC" {
+		return dailyRollup
+	}
+
+	return hourlyRollup
+}
+
+// sourceFor splits q at the watermark of t, the zero time if t is empty.
+func sourceFor(q storage.AnalyticsQuery, t rollupTable, watermark time.Time) clickSource {
+	src := clickSource{table: t, from: q.To, to: q.To}
+
+	from := timeseries.Truncate(q.From, t.unit, time.UTC)
+	if from.Before(q.From) {
+		from = timeseries.Next(from, t.unit, time.UTC)
+	}
+
+	to := timeseries.Truncate(q.To, t.unit, time.UTC)
+	if watermark.Before(to) {
+		to = watermark
+	}
+
+	if from.Before(to) {
+		src.from, src.to = from, to
+	}
+
+	return src
+}
+
+// units is a CTE with per-bucket clicks of a single url for every dimension.
+// Raw rows are bucketed by the query granularity directly. Rollup rows keep
+// their hourly or daily buckets and are regrouped by the caller.
+//
+// Parameters: $1 url id, $2-$3 rollup range, $4-$5 query range,
+// $6 granularity, $7 time zone.
+func (c clickSource) units() string {
+	return fmt.Sprintf(`WITH units AS (
+		SELECT bucket, dimension, value, clicks FROM %[1]s
+		WHERE url_id = $1 AND bucket >= $2 AND bucket < $3
+		UNION ALL
+		SELECT date_trunc($6, a.created_at, $7), d.dimension, d.value, COUNT(*)
+		FROM url_analytics a CROSS JOIN LATERAL %[2]s
+		WHERE a.url_id = $1
+			AND ((a.created_at >= $4 AND a.created_at < $2) OR (a.created_at >= $3 AND a.created_at < $5))
+		GROUP BY 1, 2, 3
+	) `, c.table.name, dimensions)
+}
+
+// visitors is a CTE with the visitors of a single url by query bucket: one row
+// per visitor and hour of the rollup range, one row per click elsewhere.
+// Unique visitors are counted from it with COUNT(DISTINCT visitor_id), since
+// uniques of separate hours can't be added up. The second CTE, visitor_days,
+// has the counts of UTC days whose visitors were pruned; visitor IDs change
+// daily, so they are added to the distinct count. A pruned day counts towards
+// the bucket it starts in. The source must be the visitor source of
+// sources; parameters are the same as of units.
+func (c clickSource) visitors() string {
+	return `WITH visitors AS (
+		SELECT date_trunc($6, v.at, $7) AS bucket, v.visitor_id FROM (
+			SELECT bucket AS at, visitor_id FROM url_visitors_hourly
+			WHERE url_id = $1 AND bucket >= $2 AND bucket < $3
+			UNION ALL
+			SELECT created_at, visitor_id FROM url_analytics
+			WHERE url_id = $1 AND visitor_id <> ''
+				AND ((created_at >= $4 AND created_at < $2) OR (created_at >= $3 AND created_at < $5))
+		) v
+	), visitor_days AS (
+		SELECT date_trunc($6, bucket, $7) AS bucket, uniques FROM url_visitors_daily
+		WHERE url_id = $1 AND bucket >= $4 AND bucket < $5
+	) `
+}
+
+func (c clickSource) args(urlID int, q storage.AnalyticsQuery, extra ...any) []any {
+	args := []any{urlID, c.from, c.to, q.From, q.To, string(q.Granularity), q.Location.String()}
+
+	return append(args, extra...)
+}
+
+func (s *Storage) GetAnalytics(ctx context.Context, alias string, q storage.AnalyticsQuery) (storage.AnalyticsData, error) {
+	ctx, end := s.begin(ctx, "GetAnalytics")
+	defer end()
+
+	var urlID int
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM url WHERE alias = $1 AND "+inDomain(2), alias, storage.DomainFromContext(ctx)).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.AnalyticsData{}, storage.ErrURLNotFound
+		}
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get url id: %w", err)
+	}
+
+	src, vsrc, err := s.sources(ctx, q)
+	if err != nil {
+		return storage.AnalyticsData{}, err
+	}
+
+	var totalClicks int64
+	err = s.db.QueryRowContext(ctx, src.units()+`SELECT COALESCE(SUM(clicks), 0) FROM units WHERE dimension = 'total'`,
+		src.args(urlID, q)...).Scan(&totalClicks)
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get total clicks: %w", err)
+	}
+
+	var uniqueVisitors int64
+	err = s.db.QueryRowContext(ctx, vsrc.visitors()+`SELECT (SELECT COUNT(DISTINCT visitor_id) FROM visitors)
+		+ (SELECT COALESCE(SUM(uniques), 0) FROM visitor_days)::bigint`,
+		vsrc.args(urlID, q)...).Scan(&uniqueVisitors)
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't count unique visitors: %w", err)
+	}
+
+	series, err := s.series(ctx, src, vsrc, urlID, q)
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get click series: %w", err)
+	}
+
+	userAgentCounts := make(map[string]int64)
+	rows, err := s.db.QueryContext(ctx, src.units()+`SELECT value, SUM(clicks) FROM units
+		WHERE dimension = 'user_agent' GROUP BY value`, src.args(urlID, q)...)
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get user agent stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userAgent string
+		var count int64
+		if err := rows.Scan(&userAgent, &count); err != nil {
+			return storage.AnalyticsData{}, fmt.Errorf("couldn't scan user agent row: %w", err)
+		}
+		userAgentCounts[userAgent] = count
+	}
+
+	referrers, err := s.topCounts(ctx, src, urlID, q, "referrer", "direct")
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get referrer stats: %w", err)
+	}
+
+	sources, err := s.topCounts(ctx, src, urlID, q, "source", "")
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get source stats: %w", err)
+	}
+
+	countries, err := s.topCounts(ctx, src, urlID, q, "country", "")
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get country stats: %w", err)
+	}
+
+	regions, err := s.topCounts(ctx, src, urlID, q, "region", "")
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get region stats: %w", err)
+	}
+
+	cities, err := s.topCounts(ctx, src, urlID, q, "city", "")
+	if err != nil {
+		return storage.AnalyticsData{}, fmt.Errorf("couldn't get city stats: %w", err)
+	}
+
+	return storage.AnalyticsData{
+		TotalClicks:    totalClicks,
+		UniqueVisitors: uniqueVisitors,
+		Series:         series,
+		UserAgents:     userAgentCounts,
+		Referrers:      referrers,
+		Sources:        sources,
+		Countries:      countries,
+		Regions:        regions,
+		Cities:         cities,
+	}, nil
+}
+
+// CountClicks returns the clicks of a link since it was created.
+func (s *Storage) CountClicks(ctx context.Context, alias string) (int64, error) {
+	ctx, end := s.begin(ctx, "CountClicks")
+	defer end()
+
+	var urlID int
+	var createdAt time.Time
+	err := s.db.QueryRowContext(ctx, "SELECT id, created_at FROM url WHERE alias = $1 AND "+inDomain(2),
+		alias, storage.DomainFromContext(ctx)).Scan(&urlID, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrURLNotFound
+		}
+		return 0, fmt.Errorf("couldn't get url id: %w", err)
+	}
+
+	q := storage.AnalyticsQuery{From: createdAt, To: time.Now(), Granularity: timeseries.Day, Location: time.UTC}
+	src, err := s.clickSource(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+
+	var clicks int64
+	err = s.db.QueryRowContext(ctx, src.units()+`SELECT COALESCE(SUM(clicks), 0) FROM units WHERE dimension = 'total'`,
+		src.args(urlID, q)...).Scan(&clicks)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't count clicks: %w", err)
+	}
+
+	return clicks, nil
+}
+
+// GetClickSeries returns the clicks of a link per bucket of q, without the
+// breakdowns of GetAnalytics.
+func (s *Storage) GetClickSeries(ctx context.Context, alias string, q storage.AnalyticsQuery) ([]storage.Point, error) {
+	ctx, end := s.begin(ctx, "GetClickSeries")
+	defer end()
+
+	var urlID int
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM url WHERE alias = $1 AND "+inDomain(2), alias, storage.DomainFromContext(ctx)).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrURLNotFound
+		}
+		return nil, fmt.Errorf("couldn't get url id: %w", err)
+	}
+
+	src, vsrc, err := s.sources(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := s.series(ctx, src, vsrc, urlID, q)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get click series: %w", err)
+	}
+
+	return series, nil
+}
+
+// series returns clicks and uniques per bucket of q, including empty buckets.
+// src and vsrc come from sources.
+func (s *Storage) series(ctx context.Context, src, vsrc clickSource, urlID int, q storage.AnalyticsQuery) ([]storage.Point, error) {
+	byBucket := make(map[int64]storage.Point)
+
+	rows, err := s.db.QueryContext(ctx, src.units()+`SELECT date_trunc($6, bucket, $7) AS b, SUM(clicks)
+		FROM units WHERE dimension = 'total' GROUP BY b`, src.args(urlID, q)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p storage.Point
+		if err := rows.Scan(&p.Time, &p.Clicks); err != nil {
+			return nil, err
+		}
+		byBucket[p.Time.Unix()] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, vsrc.visitors()+`SELECT bucket, SUM(uniques)::bigint FROM (
+			SELECT bucket, COUNT(DISTINCT visitor_id) AS uniques FROM visitors GROUP BY bucket
+			UNION ALL
+			SELECT bucket, uniques FROM visitor_days
+		) v GROUP BY bucket`, vsrc.args(urlID, q)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b time.Time
+		var uniques int64
+		if err := rows.Scan(&b, &uniques); err != nil {
+			return nil, err
+		}
+		p := byBucket[b.Unix()]
+		p.Time, p.Uniques = b, uniques
+		byBucket[b.Unix()] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fillSeries(byBucket, q), nil
+}
+
+func fillSeries(byBucket map[int64]storage.Point, q storage.AnalyticsQuery) []storage.Point {
+	buckets := timeseries.Buckets(q.From, q.To, q.Granularity, q.Location)
+
+	points := make([]storage.Point, 0, len(buckets))
+	for _, b := range buckets {
+		p := byBucket[b.Unix()]
+		p.Time = b
+		points = append(points, p)
+	}
+
+	return points
+}
+
+// topCounts returns the most clicked values of a dimension. Empty values are
+// reported as emptyLabel, or skipped if emptyLabel is "".
+func (s *Storage) topCounts(ctx context.Context, src clickSource, urlID int, q storage.AnalyticsQuery, dimension, emptyLabel string) ([]storage.Count, error) {
+	rows, err := s.db.QueryContext(ctx, src.units()+`SELECT label, SUM(clicks) AS total FROM (
+			SELECT COALESCE(NULLIF(value, ''), NULLIF($8, '')) AS label, clicks FROM units WHERE dimension = $9
+		) v WHERE label IS NOT NULL GROUP BY label ORDER BY total DESC, label LIMIT $10`,
+		src.args(urlID, q, emptyLabel, dimension, topLimit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]storage.Count, 0, topLimit)
+	for rows.Next() {
+		var c storage.Count
+		if err := rows.Scan(&c.Value, &c.Clicks); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
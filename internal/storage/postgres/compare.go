@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// compareQuery returns every row of a comparison in one result set: a "link"
+// row per found alias, "series" rows per bucket, a "visitors" row with the
+// unique visitors of the whole range and the top values of each dimension,
+// ordered by rank. Visitors are counted like in GetAnalytics.
+//
+// Parameters: $1 aliases, $2-$3 rollup range, $4-$5 query range,
+// $6 granularity, $7 time zone, $8 top limit, $9 domain,
+// $10-$11 visitor rollup range.
+func (c clickSource) compareQuery() string {
+	return fmt.Sprintf(`WITH links AS (
+		SELECT id, alias FROM url WHERE alias = ANY($1) AND %[3]s
+	), units AS (
+		SELECT url_id, bucket, dimension, value, clicks FROM %[1]s
+		WHERE url_id IN (SELECT id FROM links) AND bucket >= $2 AND bucket < $3
+		UNION ALL
+		SELECT a.url_id, date_trunc($6, a.created_at, $7), d.dimension, d.value, COUNT(*)
+		FROM url_analytics a CROSS JOIN LATERAL %[2]s
+		WHERE a.url_id IN (SELECT id FROM links)
+			AND ((a.created_at >= $4 AND a.created_at < $2) OR (a.created_at >= $3 AND a.created_at < $5))
+		GROUP BY 1, 2, 3, 4
+	), visitors AS (
+		SELECT v.url_id, date_trunc($6, v.at, $7) AS b, v.visitor_id FROM (
+			SELECT url_id, bucket AS at, visitor_id FROM url_visitors_hourly
+			WHERE url_id IN (SELECT id FROM links) AND bucket >= $10 AND bucket < $11
+			UNION ALL
+			SELECT url_id, created_at, visitor_id FROM url_analytics
+			WHERE url_id IN (SELECT id FROM links) AND visitor_id <> ''
+				AND ((created_at >= $4 AND created_at < $10) OR (created_at >= $11 AND created_at < $5))
+		) v
+	), visitor_days AS (
+		SELECT url_id, date_trunc($6, bucket, $7) AS b, uniques FROM url_visitors_daily
+		WHERE url_id IN (SELECT id FROM links) AND bucket >= $4 AND bucket < $5
+	), series AS (
+		SELECT COALESCE(c.url_id, u.url_id) AS url_id, COALESCE(c.b, u.b) AS b,
+			COALESCE(c.clicks, 0) AS clicks, COALESCE(u.uniques, 0) AS uniques
+		FROM (
+			SELECT url_id, date_trunc($6, bucket, $7) AS b, SUM(clicks) AS clicks
+			FROM units WHERE dimension = 'total' GROUP BY 1, 2
+		) c FULL JOIN (
+			SELECT url_id, b, SUM(uniques)::bigint AS uniques FROM (
+				SELECT url_id, b, COUNT(DISTINCT visitor_id) AS uniques FROM visitors GROUP BY 1, 2
+				UNION ALL
+				SELECT url_id, b, uniques FROM visitor_days
+			) v GROUP BY 1, 2
+		) u ON u.url_id = c.url_id AND u.b = c.b
+	), breakdowns AS (
+		SELECT url_id, dimension, label, clicks,
+			row_number() OVER (PARTITION BY url_id, dimension ORDER BY clicks DESC, label) AS rank
+		FROM (
+			SELECT url_id, dimension,
+				CASE WHEN dimension = 'referrer' AND value = '' THEN 'direct' ELSE value END AS label,
+				SUM(clicks) AS clicks
+			FROM units WHERE dimension <> 'total' GROUP BY 1, 2, 3
+		) v WHERE label <> '' OR dimension = 'user_agent'
+	)
+	SELECT l.alias, 'link', NULL::timestamptz, '', 0, 0, 0 FROM links l
+	UNION ALL
+	SELECT l.alias, 'series', s.b, '', s.clicks, s.uniques, 0 FROM series s JOIN links l ON l.id = s.url_id
+	UNION ALL
+	SELECT l.alias, 'visitors', NULL, '', 0, COALESCE(v.n, 0) + COALESCE(d.n, 0)::bigint, 0 FROM links l
+	LEFT JOIN (SELECT url_id, COUNT(DISTINCT visitor_id) AS n FROM visitors GROUP BY 1) v ON v.url_id = l.id
+	LEFT JOIN (SELECT url_id, SUM(uniques) AS n FROM visitor_days GROUP BY 1) d ON d.url_id = l.id
+	UNION ALL
+	SELECT l.alias, b.dimension, NULL, b.label, b.clicks, 0, b.rank FROM breakdowns b JOIN links l ON l.id = b.url_id
+	WHERE b.rank <= $8
+	ORDER BY 1, 2, 7`, c.table.name, dimensions, inDomain(9))
+}
+
+// CompareAnalytics returns the analytics of several links in the order of
+// aliases. One query reads the rollup watermarks and a second one computes
+// the comparison. All links share the buckets of q, and
+// user agents are limited to the top values like the other dimensions.
+func (s *Storage) CompareAnalytics(ctx context.Context, aliases []string, q storage.AnalyticsQuery) ([]storage.LinkAnalytics, error) {
+	ctx, end := s.begin(ctx, "CompareAnalytics")
+	defer end()
+
+	src, vsrc, err := s.sources(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, src.compareQuery(),
+		pq.Array(aliases), src.from, src.to, q.From, q.To, string(q.Granularity), q.Location.String(), topLimit,
+		storage.DomainFromContext(ctx), vsrc.from, vsrc.to)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compare analytics: %w", err)
+	}
+	defer rows.Close()
+
+	type link struct {
+		data     storage.AnalyticsData
+		byBucket map[int64]storage.Point
+	}
+	links := make(map[string]*link, len(aliases))
+
+	for rows.Next() {
+		var (
+			alias, kind, value string
+			bucket             sql.NullTime
+			clicks, uniques    int64
+			rank               int
+		)
+		if err := rows.Scan(&alias, &kind, &bucket, &value, &clicks, &uniques, &rank); err != nil {
+			return nil, fmt.Errorf("couldn't scan comparison row: %w", err)
+		}
+
+		l := links[alias]
+		if l == nil {
+			l = &link{
+				data:     storage.AnalyticsData{UserAgents: make(map[string]int64)},
+				byBucket: make(map[int64]storage.Point),
+			}
+			links[alias] = l
+		}
+
+		c := storage.Count{Value: value, Clicks: clicks}
+		switch kind {
+		case "series":
+			l.byBucket[bucket.Time.Unix()] = storage.Point{Time: bucket.Time, Clicks: clicks, Uniques: uniques}
+			l.data.TotalClicks += clicks
+		case "visitors":
+			l.data.UniqueVisitors = uniques
+		case "user_agent":
+			l.data.UserAgents[value] = clicks
+		case "referrer":
+			l.data.Referrers = append(l.data.Referrers, c)
+		case "source":
+			l.data.Sources = append(l.data.Sources, c)
+		case "country":
+			l.data.Countries = append(l.data.Countries, c)
+		case "region":
+			l.data.Regions = append(l.data.Regions, c)
+		case "city":
+			l.data.Cities = append(l.data.Cities, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't compare analytics: %w", err)
+	}
+
+	res := make([]storage.LinkAnalytics, 0, len(aliases))
+	for _, alias := range aliases {
+		l, ok := links[alias]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", storage.ErrURLNotFound, alias)
+		}
+
+		l.data.Series = fillSeries(l.byBucket, q)
+		res = append(res, storage.LinkAnalytics{Alias: alias, AnalyticsData: l.data})
+	}
+
+	return res, nil
+}
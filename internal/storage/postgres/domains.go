@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// inDomain returns a condition on url matching the links of the domain whose
+// host is passed as parameter n. An unknown or empty host matches the links
+// of the default domain.
+func inDomain(n int) string {
+	return fmt.Sprintf("domain_id IS NOT DISTINCT FROM (SELECT id FROM domain WHERE host = $%d)", n)
+}
+
+func (s *Storage) SaveDomain(ctx context.Context, host string) (storage.Domain, error) {
+	ctx, end := s.begin(ctx, "SaveDomain")
+	defer end()
+
+	d := storage.Domain{Host: host}
+	err := s.db.QueryRowContext(ctx, "INSERT INTO domain (host) VALUES ($1) RETURNING id, created_at", host).
+		Scan(&d.ID, &d.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return storage.Domain{}, storage.ErrDomainExists
+	}
+	if err != nil {
+		return storage.Domain{}, fmt.Errorf("couldn't insert domain: %w", err)
+	}
+
+	return d, nil
+}
+
+func (s *Storage) GetDomain(ctx context.Context, host string) (storage.Domain, error) {
+	ctx, end := s.begin(ctx, "GetDomain")
+	defer end()
+
+	d := storage.Domain{Host: host}
+	err := s.db.QueryRowContext(ctx, "SELECT id, created_at FROM domain WHERE host = $1", host).
+		Scan(&d.ID, &d.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Domain{}, storage.ErrDomainNotFound
+	}
+	if err != nil {
+		return storage.Domain{}, fmt.Errorf("couldn't get domain: %w", err)
+	}
+
+	return d, nil
+}
+
+func (s *Storage) ListDomains(ctx context.Context) ([]storage.Domain, error) {
+	ctx, end := s.begin(ctx, "ListDomains")
+	defer end()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, host, created_at FROM domain ORDER BY host")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list domains: %w", err)
+	}
+	defer rows.Close()
+
+	domains := []storage.Domain{}
+	for rows.Next() {
+		var d storage.Domain
+		if err := rows.Scan(&d.ID, &d.Host, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("couldn't scan domain row: %w", err)
+		}
+		domains = append(domains, d)
+	}
+
+	return domains, rows.Err()
+}
+
+// SaveAPIKey stores a key of the domain with the given host, "" for the
+// default domain.
+func (s *Storage) SaveAPIKey(ctx context.Context, prefix string, secretHash []byte, host string) (storage.APIKey, error) {
+	ctx, end := s.begin(ctx, "SaveAPIKey")
+	defer end()
+
+	var domainID sql.NullInt64
+	if host != "" {
+		err := s.db.QueryRowContext(ctx, "SELECT id FROM domain WHERE host = $1", host).Scan(&domainID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.APIKey{}, storage.ErrDomainNotFound
+		}
+		if err != nil {
+			return storage.APIKey{}, fmt.Errorf("couldn't get domain: %w", err)
+		}
+	}
+
+	k := storage.APIKey{Prefix: prefix, SecretHash: secretHash, Domain: host}
+	err := s.db.QueryRowContext(ctx, `INSERT INTO api_key (prefix, secret_hash, domain_id) VALUES ($1, $2, $3)
+		RETURNING created_at`, prefix, secretHash, domainID).Scan(&k.CreatedAt)
+	if err != nil {
+		return storage.APIKey{}, fmt.Errorf("couldn't insert api key: %w", err)
+	}
+
+	return k, nil
+}
+
+func (s *Storage) GetAPIKey(ctx context.Context, prefix string) (storage.APIKey, error) {
+	ctx, end := s.begin(ctx, "GetAPIKey")
+	defer end()
+
+	k := storage.APIKey{Prefix: prefix}
+	err := s.db.QueryRowContext(ctx, `SELECT k.secret_hash, COALESCE(d.host, ''), k.created_at
+		FROM api_key k LEFT JOIN domain d ON d.id = k.domain_id WHERE k.prefix = $1`, prefix).
+		Scan(&k.SecretHash, &k.Domain, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return storage.APIKey{}, fmt.Errorf("couldn't get api key: %w", err)
+	}
+
+	return k, nil
+}
+
+// ListAPIKeys returns every key without its secret hash.
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	ctx, end := s.begin(ctx, "ListAPIKeys")
+	defer end()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT k.prefix, COALESCE(d.host, ''), k.created_at
+		FROM api_key k LEFT JOIN domain d ON d.id = k.domain_id ORDER BY k.created_at, k.prefix`)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []storage.APIKey{}
+	for rows.Next() {
+		var k storage.APIKey
+		if err := rows.Scan(&k.Prefix, &k.Domain, &k.CreatedAt); err != nil {
+			return nil, fmt.Errorf("couldn't scan api key row: %w", err)
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+func (s *Storage) DeleteAPIKey(ctx context.Context, prefix string) error {
+	ctx, end := s.begin(ctx, "DeleteAPIKey")
+	defer end()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM api_key WHERE prefix = $1", prefix)
+	if err != nil {
+		return fmt.Errorf("couldn't delete api key: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("couldn't delete api key: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/lib/timeseries"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// GetHeatmap returns clicks of a link in the range of q by local day of week
+// and hour in q.Location. The granularity of q is ignored.
+func (s *Storage) GetHeatmap(ctx context.Context, alias string, q storage.AnalyticsQuery) (storage.Heatmap, error) {
+	ctx, end := s.begin(ctx, "GetHeatmap")
+	defer end()
+
+	var urlID int
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM url WHERE alias = $1 AND "+inDomain(2), alias, storage.DomainFromContext(ctx)).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Heatmap{}, storage.ErrURLNotFound
+		}
+		return storage.Heatmap{}, fmt.Errorf("couldn't get url id: %w", err)
+	}
+
+	// Only hourly buckets can be split by hour of day
+	q.Granularity = timeseries.Hour
+
+	src, err := s.clickSource(ctx, q)
+	if err != nil {
+		return storage.Heatmap{}, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, src.units()+`SELECT
+			EXTRACT(ISODOW FROM bucket AT TIME ZONE $7)::int AS dow,
+			EXTRACT(HOUR FROM bucket AT TIME ZONE $7)::int AS hour,
+			SUM(clicks)
+		FROM units WHERE dimension = 'total' GROUP BY dow, hour`, src.args(urlID, q)...)
+	if err != nil {
+		return storage.Heatmap{}, fmt.Errorf("couldn't get click heatmap: %w", err)
+	}
+	defer rows.Close()
+
+	var heatmap storage.Heatmap
+	for rows.Next() {
+		var dow, hour int
+		var clicks int64
+		if err := rows.Scan(&dow, &hour, &clicks); err != nil {
+			return storage.Heatmap{}, fmt.Errorf("couldn't scan heatmap row: %w", err)
+		}
+		heatmap[dow-1][hour] = clicks
+	}
+	if err := rows.Err(); err != nil {
+		return storage.Heatmap{}, fmt.Errorf("couldn't get click heatmap: %w", err)
+	}
+
+	return heatmap, nil
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type migration struct {
+	version int
+	name    string
+}
+
+// migrate applies every embedded migration that is newer than the version
+// recorded in schema_migrations. Files are named NNNN_description.sql.
+func (s *Storage) migrate() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	if err != nil {
+		return fmt.Errorf("couldn't create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return fmt.Errorf("couldn't get schema version: %w", err)
+	}
+
+	list, err := listMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		if m.version <= current {
+			continue
+		}
+
+		if err := s.applyMigration(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckSchema returns an error if migrations embedded in the binary haven't
+// been applied to the database yet.
+func (s *Storage) CheckSchema(ctx context.Context) error {
+	ctx, end := s.begin(ctx, "CheckSchema")
+	defer end()
+
+	list, err := listMigrations()
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return fmt.Errorf("couldn't get schema version: %w", err)
+	}
+
+	if want := list[len(list)-1].version; current < want {
+		return fmt.Errorf("schema version is %d, want %d", current, want)
+	}
+
+	return nil
+}
+
+func (s *Storage) applyMigration(m migration) error {
+	query, err := migrations.ReadFile("migrations/" + m.name)
+	if err != nil {
+		return fmt.Errorf("couldn't read migration %s: %w", m.name, err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("couldn't begin migration %s: %w", m.name, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(string(query)); err != nil {
+		return fmt.Errorf("couldn't apply migration %s: %w", m.name, err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", m.version); err != nil {
+		return fmt.Errorf("couldn't record migration %s: %w", m.name, err)
+	}
+
+	return tx.Commit()
+}
+
+func listMigrations() ([]migration, error) {
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list migrations: %w", err)
+	}
+
+	list := make([]migration, 0, len(entries))
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration name: %s", e.Name())
+		}
+
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name %s: %w", e.Name(), err)
+		}
+
+		list = append(list, migration{version: version, name: e.Name()})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].version < list[j].version })
+
+	return list, nil
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS url (
+    id    SERIAL PRIMARY KEY,
+    url   TEXT NOT NULL,
+    alias TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS url_analytics (
+    id         SERIAL PRIMARY KEY,
+    url_id     INTEGER NOT NULL REFERENCES url (id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_analytics_url_id ON url_analytics (url_id);
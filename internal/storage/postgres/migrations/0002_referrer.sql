@@ -0,0 +1,2 @@
+ALTER TABLE url_analytics ADD COLUMN IF NOT EXISTS referrer TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_analytics ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
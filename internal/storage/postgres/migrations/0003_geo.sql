@@ -0,0 +1,4 @@
+ALTER TABLE url_analytics ADD COLUMN IF NOT EXISTS country TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_analytics ADD COLUMN IF NOT EXISTS region TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_analytics ADD COLUMN IF NOT EXISTS city TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_analytics ADD COLUMN IF NOT EXISTS ip INET;
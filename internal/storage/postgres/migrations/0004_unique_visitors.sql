@@ -0,0 +1,6 @@
+ALTER TABLE url_analytics ADD COLUMN IF NOT EXISTS visitor_id TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS visitor_salts (
+    day  DATE PRIMARY KEY,
+    salt BYTEA NOT NULL
+);
@@ -0,0 +1,6 @@
+-- existing values were written with NOW() in the server time zone, which is
+-- how Postgres interprets them during the conversion
+ALTER TABLE url_analytics ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_url_analytics_url_id_created_at ON url_analytics (url_id, created_at);
+DROP INDEX IF EXISTS idx_url_analytics_url_id;
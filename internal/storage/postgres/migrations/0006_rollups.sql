@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS url_clicks_hourly (
+    url_id    INTEGER NOT NULL REFERENCES url (id) ON DELETE CASCADE,
+    bucket    TIMESTAMPTZ NOT NULL,
+    dimension TEXT NOT NULL,
+    value     TEXT NOT NULL,
+    clicks    BIGINT NOT NULL,
+    uniques   BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (url_id, dimension, bucket, value)
+);
+
+CREATE TABLE IF NOT EXISTS url_clicks_daily (
+    url_id    INTEGER NOT NULL REFERENCES url (id) ON DELETE CASCADE,
+    bucket    TIMESTAMPTZ NOT NULL,
+    dimension TEXT NOT NULL,
+    value     TEXT NOT NULL,
+    clicks    BIGINT NOT NULL,
+    uniques   BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (url_id, dimension, bucket, value)
+);
+
+-- rolled_until is the exclusive upper bound of raw clicks already aggregated
+CREATE TABLE IF NOT EXISTS rollup_state (
+    name         TEXT PRIMARY KEY,
+    rolled_until TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_analytics_created_at ON url_analytics (created_at);
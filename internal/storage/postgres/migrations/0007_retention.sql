@@ -0,0 +1,2 @@
+-- NULL uses the global retention period, 0 keeps raw clicks forever
+ALTER TABLE url ADD COLUMN IF NOT EXISTS raw_retention_seconds BIGINT;
@@ -0,0 +1,58 @@
+-- Moves url_analytics to a table partitioned by month of created_at.
+-- Existing rows are copied in this transaction, so on large tables run the
+-- migration in a maintenance window. Partitions are named
+-- url_analytics_YYYY_MM and cover UTC calendar months.
+SET LOCAL TIME ZONE 'UTC';
+
+ALTER INDEX IF EXISTS url_analytics_pkey RENAME TO url_analytics_legacy_pkey;
+DROP INDEX IF EXISTS idx_url_analytics_url_id_created_at;
+DROP INDEX IF EXISTS idx_url_analytics_created_at;
+ALTER TABLE url_analytics RENAME TO url_analytics_legacy;
+
+CREATE SEQUENCE IF NOT EXISTS url_analytics_event_id_seq AS BIGINT;
+
+CREATE TABLE url_analytics (
+    id         BIGINT NOT NULL DEFAULT nextval('url_analytics_event_id_seq'),
+    url_id     INTEGER NOT NULL REFERENCES url (id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    referrer   TEXT NOT NULL DEFAULT '',
+    source     TEXT NOT NULL DEFAULT '',
+    country    TEXT NOT NULL DEFAULT '',
+    region     TEXT NOT NULL DEFAULT '',
+    city       TEXT NOT NULL DEFAULT '',
+    ip         INET,
+    visitor_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+ALTER SEQUENCE url_analytics_event_id_seq OWNED BY url_analytics.id;
+
+CREATE INDEX idx_url_analytics_url_id_created_at ON url_analytics (url_id, created_at);
+CREATE INDEX idx_url_analytics_created_at ON url_analytics (created_at);
+
+-- catches rows outside the created partitions if maintenance falls behind
+CREATE TABLE url_analytics_default PARTITION OF url_analytics DEFAULT;
+
+DO $$
+DECLARE
+    m TIMESTAMPTZ;
+BEGIN
+    FOR m IN SELECT generate_series(
+        date_trunc('month', COALESCE((SELECT MIN(created_at) FROM url_analytics_legacy), NOW())),
+        date_trunc('month', NOW()) + INTERVAL '3 months',
+        INTERVAL '1 month'
+    )
+    LOOP
+        EXECUTE format('CREATE TABLE %I PARTITION OF url_analytics FOR VALUES FROM (%L) TO (%L)',
+            'url_analytics_' || to_char(m, 'YYYY_MM'), m, m + INTERVAL '1 month');
+    END LOOP;
+END $$;
+
+INSERT INTO url_analytics (id, url_id, user_agent, referrer, source, country, region, city, ip, visitor_id, created_at)
+SELECT id, url_id, user_agent, referrer, source, country, region, city, ip, visitor_id, created_at
+FROM url_analytics_legacy;
+
+SELECT setval('url_analytics_event_id_seq', COALESCE((SELECT MAX(id) FROM url_analytics), 0) + 1, false);
+
+DROP TABLE url_analytics_legacy;
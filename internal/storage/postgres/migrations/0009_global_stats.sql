@@ -0,0 +1,3 @@
+-- The primary keys start with url_id, which doesn't help queries across all links
+CREATE INDEX IF NOT EXISTS idx_url_clicks_hourly_total ON url_clicks_hourly (bucket) WHERE dimension = 'total';
+CREATE INDEX IF NOT EXISTS idx_url_clicks_daily_total ON url_clicks_daily (bucket) WHERE dimension = 'total';
@@ -0,0 +1,8 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- links created before this migration are dated by their first recorded click
+UPDATE url SET created_at = first.at
+FROM (
+    SELECT url_id, MIN(created_at) AS at FROM url_analytics GROUP BY url_id
+) AS first
+WHERE first.url_id = url.id AND first.at < url.created_at;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS domain (
+    id         SERIAL PRIMARY KEY,
+    host       TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- links without a domain belong to the default domain of the deployment
+ALTER TABLE url ADD COLUMN IF NOT EXISTS domain_id INTEGER REFERENCES domain (id);
+
+-- aliases are unique per domain; 0 stands in for the default domain
+ALTER TABLE url DROP CONSTRAINT IF EXISTS url_alias_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url_domain_alias ON url (COALESCE(domain_id, 0), alias);
@@ -0,0 +1,2 @@
+-- links opt in to exposing their click counts in embeddable badges
+ALTER TABLE url ADD COLUMN IF NOT EXISTS analytics_public BOOLEAN NOT NULL DEFAULT FALSE;
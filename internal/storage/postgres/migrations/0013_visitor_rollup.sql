@@ -0,0 +1,32 @@
+-- Distinct visitors of a url per UTC hour. Unique visitors of any bucket made
+-- of whole hours are counted from it, since the uniques of hourly rollup rows
+-- can't be added up: a visitor active in several hours would count repeatedly.
+SET LOCAL TIME ZONE 'UTC';
+
+CREATE TABLE IF NOT EXISTS url_visitors_hourly (
+    url_id     INTEGER NOT NULL REFERENCES url (id) ON DELETE CASCADE,
+    bucket     TIMESTAMPTZ NOT NULL,
+    visitor_id TEXT NOT NULL,
+    PRIMARY KEY (url_id, bucket, visitor_id)
+);
+
+-- raw clicks that are already rolled up
+INSERT INTO url_visitors_hourly (url_id, bucket, visitor_id)
+SELECT DISTINCT url_id, date_trunc('hour', created_at), visitor_id
+FROM url_analytics
+WHERE visitor_id <> ''
+    AND created_at < (SELECT rolled_until FROM rollup_state WHERE name = 'url_clicks_hourly')
+ON CONFLICT DO NOTHING;
+
+-- Days whose raw clicks were pruned only have a daily count left. They get
+-- placeholder visitors at the start of the day, so daily and longer buckets
+-- keep their uniques; hourly buckets of those days can't be restored.
+INSERT INTO url_visitors_hourly (url_id, bucket, visitor_id)
+SELECT d.url_id, d.bucket, 'legacy:' || to_char(d.bucket, 'YYYY-MM-DD') || ':' || n
+FROM url_clicks_daily d CROSS JOIN LATERAL generate_series(1, d.uniques) AS n
+WHERE d.dimension = 'total' AND d.uniques > 0
+    AND NOT EXISTS (
+        SELECT 1 FROM url_analytics a
+        WHERE a.url_id = d.url_id AND a.created_at >= d.bucket AND a.created_at < d.bucket + INTERVAL '1 day'
+    )
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- Unique visitors are counted from url_visitors_hourly; uniques of rollup
+-- buckets can't be added up across buckets and are no longer read.
+ALTER TABLE url_clicks_hourly DROP COLUMN IF EXISTS uniques;
+ALTER TABLE url_clicks_daily DROP COLUMN IF EXISTS uniques;
@@ -0,0 +1,8 @@
+-- Raw clicks are rolled up by the time they were inserted, so that clicks
+-- saved after their bucket was aggregated are still added to it. Existing
+-- rows keep a NULL inserted_at and are rolled up by created_at as before;
+-- the default is set separately so that they don't get the migration time.
+ALTER TABLE url_analytics ADD COLUMN IF NOT EXISTS inserted_at TIMESTAMPTZ;
+ALTER TABLE url_analytics ALTER COLUMN inserted_at SET DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_url_analytics_rolled_at ON url_analytics ((COALESCE(inserted_at, created_at)));
@@ -0,0 +1,20 @@
+-- Distinct visitors of a url per UTC day whose hourly visitors were pruned
+-- along with the link's raw clicks. Visitor IDs change daily, so the counts of
+-- separate days add up.
+SET LOCAL TIME ZONE 'UTC';
+
+CREATE TABLE IF NOT EXISTS url_visitors_daily (
+    url_id  INTEGER NOT NULL REFERENCES url (id) ON DELETE CASCADE,
+    bucket  TIMESTAMPTZ NOT NULL,
+    uniques BIGINT NOT NULL,
+    PRIMARY KEY (url_id, bucket)
+);
+
+-- placeholders for days pruned before url_visitors_hourly existed
+INSERT INTO url_visitors_daily AS d (url_id, bucket, uniques)
+SELECT url_id, date_trunc('day', bucket), COUNT(*) FROM url_visitors_hourly
+WHERE visitor_id LIKE 'legacy:%'
+GROUP BY 1, 2
+ON CONFLICT (url_id, bucket) DO UPDATE SET uniques = d.uniques + EXCLUDED.uniques;
+
+DELETE FROM url_visitors_hourly WHERE visitor_id LIKE 'legacy:%';
@@ -0,0 +1,10 @@
+-- API keys select the domain links are created on. Only the hash of the
+-- secret part of a key is stored; the prefix identifies the key.
+CREATE TABLE IF NOT EXISTS api_key (
+    id          SERIAL PRIMARY KEY,
+    prefix      TEXT NOT NULL UNIQUE,
+    secret_hash BYTEA NOT NULL,
+    -- NULL stands for the default domain
+    domain_id   INTEGER REFERENCES domain (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
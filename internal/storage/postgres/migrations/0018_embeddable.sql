@@ -0,0 +1,2 @@
+-- The flag only opens badges, sparklines and preview counts, not analytics.
+ALTER TABLE url RENAME COLUMN analytics_public TO embeddable;
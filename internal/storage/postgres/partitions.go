@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/lib/timeseries"
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	partitionPrefix = "url_analytics_"
+	partitionLayout = "2006_01"
+	// defaultPartition catches clicks outside the monthly partitions.
+	defaultPartition = "url_analytics_default"
+	// partitionsAhead is how many future months get a partition in advance.
+	partitionsAhead = 3
+)
+
+// MaintainPartitions creates monthly partitions of url_analytics up to
+// partitionsAhead months after now and removes partitions whose clicks are
+// all rolled up and past the longest retention period in effect. Removed
+// partitions are dropped, or only detached if detach is set.
+func (s *Storage) MaintainPartitions(ctx context.Context, now time.Time, global time.Duration, detach bool) (storage.PartitionReport, error) {
+	ctx, end := s.begin(ctx, "MaintainPartitions")
+	defer end()
+
+	var report storage.PartitionReport
+
+	existing, err := s.partitions(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	month := timeseries.Truncate(now, timeseries.Month, time.UTC)
+	for i := 0; i <= partitionsAhead; i++ {
+		start := month.AddDate(0, i, 0)
+		name := partitionPrefix + start.Format(partitionLayout)
+		if _, ok := existing[name]; ok {
+			continue
+		}
+
+		if err := s.createPartition(ctx, name, start); err != nil {
+			return report, fmt.Errorf("couldn't create partition %s: %w", name, err)
+		}
+		report.Created = append(report.Created, name)
+	}
+
+	cutoff, err := s.partitionCutoff(ctx, now, global)
+	if err != nil {
+		return report, err
+	}
+	if cutoff.IsZero() {
+		return report, nil
+	}
+
+	for name, start := range existing {
+		if start.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+
+		query := "DROP TABLE " + name
+		if detach {
+			query = "ALTER TABLE url_analytics DETACH PARTITION " + name
+		}
+
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return report, fmt.Errorf("couldn't remove partition %s: %w", name, err)
+		}
+		report.Removed = append(report.Removed, name)
+	}
+
+	return report, nil
+}
+
+// createPartition creates the partition of the month starting at start. A
+// partition can't be created while the default partition holds rows of its
+// range, so the default partition is detached, its rows of the month are moved
+// into the new partition and it is attached back, all in one transaction.
+func (s *Storage) createPartition(ctx context.Context, name string, start time.Time) error {
+	from, to := start.Format(time.RFC3339), start.AddDate(0, 1, 0).Format(time.RFC3339)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, query := range []string{
+		"ALTER TABLE url_analytics DETACH PARTITION " + defaultPartition,
+		fmt.Sprintf("CREATE TABLE %s PARTITION OF url_analytics FOR VALUES FROM ('%s') TO ('%s')", name, from, to),
+		fmt.Sprintf(`WITH moved AS (
+			DELETE FROM %[1]s WHERE created_at >= '%[3]s' AND created_at < '%[4]s' RETURNING *
+		) INSERT INTO %[2]s SELECT * FROM moved`, defaultPartition, name, from, to),
+		"ALTER TABLE url_analytics ATTACH PARTITION " + defaultPartition + " DEFAULT",
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// partitions returns the monthly partitions of url_analytics by name with
+// the start of their month.
+func (s *Storage) partitions(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'url_analytics'::regclass`)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list partitions: %w", err)
+	}
+	defer rows.Close()
+
+	partitions := make(map[string]time.Time)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("couldn't scan partition row: %w", err)
+		}
+
+		start, err := time.Parse(partitionLayout, strings.TrimPrefix(name, partitionPrefix))
+		if err != nil {
+			// the default partition and anything not created by us
+			continue
+		}
+		partitions[name] = start
+	}
+
+	return partitions, rows.Err()
+}
+
+// partitionCutoff returns the time before which no click needs to be kept,
+// or the zero time if some link keeps its raw clicks forever.
+func (s *Storage) partitionCutoff(ctx context.Context, now time.Time, global time.Duration) (time.Time, error) {
+	rolledUntil, err := s.rolledUntil(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if rolledUntil.IsZero() {
+		return time.Time{}, nil
+	}
+
+	var forever bool
+	var longest int64
+	err = s.db.QueryRowContext(ctx, `SELECT COALESCE(bool_or(COALESCE(raw_retention_seconds, $1) = 0), $1 = 0),
+		COALESCE(MAX(COALESCE(raw_retention_seconds, $1)), $1) FROM url`,
+		int64(global.Seconds())).Scan(&forever, &longest)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("couldn't get longest retention: %w", err)
+	}
+	if forever {
+		return time.Time{}, nil
+	}
+
+	cutoff := now.Add(-time.Duration(longest) * time.Second)
+	if rolledUntil.Before(cutoff) {
+		cutoff = rolledUntil
+	}
+
+	return cutoff, nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorage connects to the database in TEST_POSTGRES_DSN and applies the
+// migrations, or skips the test if it isn't set.
+func testStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := &Storage{db: db}
+	require.NoError(t, s.migrate())
+
+	return s
+}
+
+func TestMaintainPartitions_DefaultPartitionRows(t *testing.T) {
+	s := testStorage(t)
+	ctx := context.Background()
+
+	// far enough ahead that no partition of these months exists yet
+	now := time.Date(2999, time.March, 15, 0, 0, 0, 0, time.UTC)
+	clickTime := time.Date(2999, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	link, err := s.SaveURL(ctx, "https://example.com", "partition-test", storage.LinkOptions{})
+	require.NoError(t, err)
+	urlID := link.ID
+
+	t.Cleanup(func() {
+		for i := 0; i <= partitionsAhead; i++ {
+			_, _ = s.db.Exec("DROP TABLE IF EXISTS " + partitionPrefix + now.AddDate(0, i, 0).Format(partitionLayout))
+		}
+		_, _ = s.db.Exec("DELETE FROM url WHERE id = $1", urlID)
+	})
+
+	_, err = s.db.Exec("INSERT INTO url_analytics (url_id, created_at) VALUES ($1, $2)", urlID, clickTime)
+	require.NoError(t, err)
+
+	report, err := s.MaintainPartitions(ctx, now, 0, false)
+	require.NoError(t, err)
+	assert.Contains(t, report.Created, "url_analytics_2999_03")
+
+	var partition string
+	err = s.db.QueryRow("SELECT tableoid::regclass::text FROM url_analytics WHERE url_id = $1", urlID).Scan(&partition)
+	require.NoError(t, err)
+	assert.Equal(t, "url_analytics_2999_03", partition)
+
+	var isDefault bool
+	err = s.db.QueryRow(`SELECT pg_get_expr(c.relpartbound, c.oid) = 'DEFAULT' FROM pg_class c
+		WHERE c.relname = $1`, defaultPartition).Scan(&isDefault)
+	require.NoError(t, err)
+	assert.True(t, isDefault)
+}
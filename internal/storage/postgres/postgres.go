@@ -3,16 +3,30 @@ package postgres
 import (
 	"analiticsURLShortener/internal/config"
 	"analiticsURLShortener/internal/storage"
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("analiticsURLShortener/internal/storage/postgres")
+
 type Storage struct {
-	db *sql.DB
+	db       *sql.DB
+	observer QueryObserver
+}
+
+// QueryObserver is notified of the duration of every storage call.
+type QueryObserver interface {
+	ObserveQuery(method string, d time.Duration)
 }
 
 func InitDB(cfg *config.Config) (*Storage, error) {
@@ -36,30 +50,141 @@ func InitDB(cfg *config.Config) (*Storage, error) {
 		return nil, err
 	}
 
-	return &Storage{db: db}, nil
+	s := &Storage{db: db}
+
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SetObserver makes s report call durations to o. It must be called before
+// the storage is used.
+func (s *Storage) SetObserver(o QueryObserver) {
+	s.observer = o
+}
+
+// begin starts a span for a storage call. The returned func ends it and
+// reports the call duration to the observer.
+func (s *Storage) begin(ctx context.Context, method string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "postgres."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "postgresql")),
+	)
+
+	return ctx, func() {
+		span.End()
+		if s.observer != nil {
+			s.observer.ObserveQuery(method, time.Since(start))
+		}
+	}
+}
+
+// DB returns the connection pool, e.g. to export its statistics.
+func (s *Storage) DB() *sql.DB {
+	return s.db
 }
 
-func (s *Storage) SaveURL(urlToSave, alias string) (int64, error) {
-	var id int64
-	err := s.db.QueryRow("INSERT INTO url (url, alias) VALUES ($1, $2) RETURNING id", urlToSave, alias).Scan(&id)
+// Close closes the connection pool.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	ctx, end := s.begin(ctx, "Ping")
+	defer end()
+
+	return s.db.PingContext(ctx)
+}
+
+func (s *Storage) SaveURL(ctx context.Context, urlToSave, alias string, opts storage.LinkOptions) (storage.Link, error) {
+	ctx, end := s.begin(ctx, "SaveURL")
+	defer end()
+
+	var seconds *int64
+	if opts.RawRetention != nil {
+		v := int64(opts.RawRetention.Seconds())
+		seconds = &v
+	}
+
+	link := storage.Link{Alias: alias, URL: urlToSave, Domain: storage.DomainFromContext(ctx)}
+	var retention sql.NullInt64
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO url (url, alias, domain_id, raw_retention_seconds, embeddable)
+		VALUES ($1, $2, (SELECT id FROM domain WHERE host = $3), $4, $5)
+		RETURNING id, created_at, raw_retention_seconds, embeddable`,
+		urlToSave, alias, link.Domain, seconds, opts.Embeddable,
+	).Scan(&link.ID, &link.CreatedAt, &retention, &link.Options.Embeddable)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return storage.Link{}, storage.ErrURLExists
+	}
+	if err != nil {
+		return storage.Link{}, fmt.Errorf("couldn't insert URL: %v", err)
+	}
+
+	link.Options.RawRetention = retentionOption(retention)
+
+	return link, nil
+}
+
+// GetLink returns the link with its options.
+func (s *Storage) GetLink(ctx context.Context, alias string) (storage.Link, error) {
+	ctx, end := s.begin(ctx, "GetLink")
+	defer end()
+
+	link := storage.Link{Alias: alias, Domain: storage.DomainFromContext(ctx)}
+	var retention sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `SELECT id, url, created_at, raw_retention_seconds, embeddable
+		FROM url WHERE alias = $1 AND `+inDomain(2), alias, link.Domain,
+	).Scan(&link.ID, &link.URL, &link.CreatedAt, &retention, &link.Options.Embeddable)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Link{}, storage.ErrURLNotFound
+	}
 	if err != nil {
-		return 0, fmt.Errorf("couldn't insert URL: %v", err)
+		return storage.Link{}, fmt.Errorf("couldn't get link: %w", err)
 	}
-	return id, nil
+
+	link.Options.RawRetention = retentionOption(retention)
+
+	return link, nil
 }
 
-func (s *Storage) GetURL(alias string) (string, error) {
+func retentionOption(seconds sql.NullInt64) *time.Duration {
+	if !seconds.Valid {
+		return nil
+	}
+
+	keep := time.Duration(seconds.Int64) * time.Second
+	return &keep
+}
+
+func (s *Storage) GetURL(ctx context.Context, alias string) (string, error) {
+	ctx, end := s.begin(ctx, "GetURL")
+	defer end()
+
 	var url string
-	err := s.db.QueryRow("SELECT url FROM url WHERE alias = $1", alias).Scan(&url)
+	err := s.db.QueryRowContext(ctx, "SELECT url FROM url WHERE alias = $1 AND "+inDomain(2), alias, storage.DomainFromContext(ctx)).Scan(&url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.ErrURLNotFound
+	}
 	if err != nil {
 		return "", fmt.Errorf("couldn't get URL: %v", err)
 	}
 	return url, nil
 }
 
-func (s *Storage) SaveAnalytics(alias string, userAgent string) error {
+// SaveClick stores a click at the time it happened, which may be well before
+// it is saved. Times ahead of the database clock are clamped to it, so a click
+// is never stored after its insertion time.
+func (s *Storage) SaveClick(ctx context.Context, e storage.ClickEvent) error {
+	ctx, end := s.begin(ctx, "SaveClick")
+	defer end()
+
 	var urlID int
-	err := s.db.QueryRow("SELECT id FROM url WHERE alias = $1", alias).Scan(&urlID)
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM url WHERE alias = $1 AND "+inDomain(2), e.Alias, storage.DomainFromContext(ctx)).Scan(&urlID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return storage.ErrURLNotFound
@@ -67,7 +192,10 @@ func (s *Storage) SaveAnalytics(alias string, userAgent string) error {
 		return fmt.Errorf("couldn't get url id: %w", err)
 	}
 
-	_, err = s.db.Exec("INSERT INTO url_analytics (url_id, user_agent) VALUES ($1, $2)", urlID, userAgent)
+	_, err = s.db.ExecContext(ctx, `INSERT INTO url_analytics
+		(url_id, user_agent, referrer, source, country, region, city, ip, visitor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::inet, $9, LEAST($10, NOW()))`,
+		urlID, e.UserAgent, e.Referrer, e.Source, e.Country, e.Region, e.City, e.IP, e.VisitorID, e.Time)
 	if err != nil {
 		return fmt.Errorf("couldn't save analytics: %w", err)
 	}
@@ -75,74 +203,75 @@ func (s *Storage) SaveAnalytics(alias string, userAgent string) error {
 	return nil
 }
 
-func (s *Storage) GetAnalytics(alias string) (storage.AnalyticsData, error) {
-	var urlID int
-	err := s.db.QueryRow("SELECT id FROM url WHERE alias = $1", alias).Scan(&urlID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return storage.AnalyticsData{}, storage.ErrURLNotFound
-		}
-		return storage.AnalyticsData{}, fmt.Errorf("couldn't get url id: %w", err)
-	}
+// VisitorSalt returns the salt used to hash visitor IDs on the given UTC day,
+// creating it on first use. Salts older than the previous day are deleted so
+// that old visitor IDs can no longer be linked back to an IP address. The
+// previous day's salt is kept for clicks that are saved after midnight.
+func (s *Storage) VisitorSalt(ctx context.Context, day time.Time) ([]byte, error) {
+	ctx, end := s.begin(ctx, "VisitorSalt")
+	defer end()
 
-	var totalClicks int64
-	err = s.db.QueryRow("SELECT COUNT(*) FROM url_analytics WHERE url_id = $1", urlID).Scan(&totalClicks)
-	if err != nil {
-		return storage.AnalyticsData{}, fmt.Errorf("couldn't get total clicks: %w", err)
+	day = day.UTC().Truncate(24 * time.Hour)
+
+	candidate := make([]byte, 32)
+	if _, err := rand.Read(candidate); err != nil {
+		return nil, fmt.Errorf("couldn't generate salt: %w", err)
 	}
 
-	userAgentCounts := make(map[string]int64)
-	rows, err := s.db.Query("SELECT user_agent, COUNT(*) FROM url_analytics WHERE url_id = $1 GROUP BY user_agent", urlID)
+	_, err := s.db.ExecContext(ctx, "INSERT INTO visitor_salts (day, salt) VALUES ($1, $2) ON CONFLICT (day) DO NOTHING", day, candidate)
 	if err != nil {
-		return storage.AnalyticsData{}, fmt.Errorf("couldn't get user agent stats: %w", err)
+		return nil, fmt.Errorf("couldn't save salt: %w", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var userAgent string
-		var count int64
-		if err := rows.Scan(&userAgent, &count); err != nil {
-			return storage.AnalyticsData{}, fmt.Errorf("couldn't scan user agent row: %w", err)
-		}
-		userAgentCounts[userAgent] = count
+	var salt []byte
+	err = s.db.QueryRowContext(ctx, "SELECT salt FROM visitor_salts WHERE day = $1", day).Scan(&salt)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get salt: %w", err)
 	}
 
-	dailyCounts := make(map[string]int64)
-	rows, err = s.db.Query("SELECT DATE(created_at), COUNT(*) FROM url_analytics WHERE url_id = $1 GROUP BY DATE(created_at)", urlID)
+	_, err = s.db.ExecContext(ctx, "DELETE FROM visitor_salts WHERE day < $1", day.AddDate(0, 0, -1))
 	if err != nil {
-		return storage.AnalyticsData{}, fmt.Errorf("couldn't get daily stats: %w", err)
+		return nil, fmt.Errorf("couldn't delete old salts: %w", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var date string
-		var count int64
-		if err := rows.Scan(&date, &count); err != nil {
-			return storage.AnalyticsData{}, fmt.Errorf("couldn't scan daily row: %w", err)
+	return salt, nil
+}
+
+// StreamClicks calls fn for every raw click of the link in [from, to), oldest
+// first. Rows are read from the database as fn consumes them.
+func (s *Storage) StreamClicks(ctx context.Context, alias string, from, to time.Time, fn func(storage.ClickEvent) error) error {
+	ctx, end := s.begin(ctx, "StreamClicks")
+	defer end()
+
+	var urlID int
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM url WHERE alias = $1 AND "+inDomain(2), alias, storage.DomainFromContext(ctx)).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrURLNotFound
 		}
-		dailyCounts[date] = count
+		return fmt.Errorf("couldn't get url id: %w", err)
 	}
 
-	monthlyCounts := make(map[string]int64)
-	rows, err = s.db.Query("SELECT to_char(created_at, 'YYYY-MM'), COUNT(*) FROM url_analytics WHERE url_id = $1 GROUP BY to_char(created_at, 'YYYY-MM')", urlID)
+	rows, err := s.db.QueryContext(ctx, `SELECT created_at, user_agent, referrer, source, country, region, city,
+		COALESCE(host(ip), ''), visitor_id
+		FROM url_analytics WHERE url_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id`, urlID, from, to)
 	if err != nil {
-		return storage.AnalyticsData{}, fmt.Errorf("couldn't get monthly stats: %w", err)
+		return fmt.Errorf("couldn't get clicks: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var month string
-		var count int64
-		if err := rows.Scan(&month, &count); err != nil {
-			return storage.AnalyticsData{}, fmt.Errorf("couldn't scan monthly row: %w", err)
+		e := storage.ClickEvent{Domain: storage.DomainFromContext(ctx), Alias: alias}
+		err := rows.Scan(&e.Time, &e.UserAgent, &e.Referrer, &e.Source, &e.Country, &e.Region, &e.City, &e.IP, &e.VisitorID)
+		if err != nil {
+			return fmt.Errorf("couldn't scan click row: %w", err)
+		}
+
+		if err := fn(e); err != nil {
+			return err
 		}
-		monthlyCounts[month] = count
 	}
 
-	return storage.AnalyticsData{
-		TotalClicks: totalClicks,
-		UserAgents:  userAgentCounts,
-		Daily:       dailyCounts,
-		Monthly:     monthlyCounts,
-	}, nil
+	return rows.Err()
 }
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVisitorSalt_KeepsPreviousDay(t *testing.T) {
+	s := testStorage(t)
+	ctx := context.Background()
+
+	// far enough ahead not to touch the salts of real clicks
+	day := time.Date(2999, time.March, 10, 0, 0, 0, 0, time.UTC)
+	t.Cleanup(func() { _, _ = s.db.Exec("DELETE FROM visitor_salts WHERE day >= $1", day.AddDate(0, 0, -2)) })
+
+	old, err := s.VisitorSalt(ctx, day.AddDate(0, 0, -2))
+	require.NoError(t, err)
+	yesterday, err := s.VisitorSalt(ctx, day.AddDate(0, 0, -1))
+	require.NoError(t, err)
+
+	_, err = s.VisitorSalt(ctx, day.Add(time.Hour))
+	require.NoError(t, err)
+
+	// a late click of the previous day gets the same salt as before
+	again, err := s.VisitorSalt(ctx, day.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, yesterday, again)
+
+	var n int
+	err = s.db.QueryRow("SELECT COUNT(*) FROM visitor_salts WHERE day = $1 AND salt = $2", day.AddDate(0, 0, -2), old).Scan(&n)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+const pruneBatchSize = 10000
+
+// expiredClicks matches raw clicks that are past their link's retention
+// period and already aggregated into both rollup tables.
+// Parameters: $1 global retention in seconds, $2 now, $3 rollup watermark.
+const expiredClicks = `FROM url_analytics a JOIN url u ON u.id = a.url_id
+	WHERE COALESCE(u.raw_retention_seconds, $1) > 0
+		AND a.created_at < $3 AND ` + rolledAt + ` < $3
+		AND a.created_at < $2::timestamptz - make_interval(secs => COALESCE(u.raw_retention_seconds, $1))`
+
+// PruneClicks deletes expired raw clicks in batches and returns the number
+// of deleted rows. global is the retention period for links without an
+// override; 0 keeps their clicks forever.
+func (s *Storage) PruneClicks(ctx context.Context, now time.Time, global time.Duration) (int64, error) {
+	ctx, end := s.begin(ctx, "PruneClicks")
+	defer end()
+
+	cutoff, err := s.rolledUntil(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if cutoff.IsZero() {
+		return 0, nil
+	}
+
+	var total int64
+	for {
+		res, err := s.db.ExecContext(ctx, `DELETE FROM url_analytics WHERE (id, created_at) IN (
+			SELECT a.id, a.created_at `+expiredClicks+` LIMIT $4)`,
+			int64(global.Seconds()), now, cutoff, pruneBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("couldn't delete expired clicks: %w", err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("couldn't get deleted rows: %w", err)
+		}
+		total += n
+
+		if n < pruneBatchSize {
+			break
+		}
+	}
+
+	if err := s.pruneVisitors(ctx, now, global, cutoff); err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+// pruneVisitors replaces the hourly visitors of whole UTC days past the
+// link's retention period with their daily count in url_visitors_daily.
+// Visitor IDs change daily, so the counts of separate days still add up.
+func (s *Storage) pruneVisitors(ctx context.Context, now time.Time, global time.Duration, cutoff time.Time) error {
+	_, err := s.db.ExecContext(ctx, `WITH expired AS (
+			DELETE FROM url_visitors_hourly v USING url u
+			WHERE u.id = v.url_id AND COALESCE(u.raw_retention_seconds, $1) > 0
+				AND v.bucket < date_trunc('day', $3::timestamptz, 'UTC')
+				AND v.bucket < date_trunc('day', $2::timestamptz - make_interval(secs => COALESCE(u.raw_retention_seconds, $1)), 'UTC')
+			RETURNING v.url_id, v.bucket, v.visitor_id
+		)
+		INSERT INTO url_visitors_daily AS d (url_id, bucket, uniques)
+		SELECT url_id, date_trunc('day', bucket, 'UTC'), COUNT(DISTINCT visitor_id) FROM expired GROUP BY 1, 2
+		ON CONFLICT (url_id, bucket) DO UPDATE SET uniques = d.uniques + EXCLUDED.uniques`,
+		int64(global.Seconds()), now, cutoff)
+	if err != nil {
+		return fmt.Errorf("couldn't prune visitors: %w", err)
+	}
+
+	return nil
+}
+
+// PruneReport returns, per link, the raw clicks PruneClicks would delete.
+func (s *Storage) PruneReport(ctx context.Context, now time.Time, global time.Duration) ([]storage.PruneStat, error) {
+	ctx, end := s.begin(ctx, "PruneReport")
+	defer end()
+
+	cutoff, err := s.rolledUntil(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cutoff.IsZero() {
+		return nil, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT u.alias, COUNT(*), MIN(a.created_at), MAX(a.created_at) `+expiredClicks+`
+		GROUP BY u.alias ORDER BY COUNT(*) DESC, u.alias`,
+		int64(global.Seconds()), now, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get expired clicks: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []storage.PruneStat
+	for rows.Next() {
+		var st storage.PruneStat
+		if err := rows.Scan(&st.Alias, &st.Clicks, &st.Oldest, &st.Newest); err != nil {
+			return nil, fmt.Errorf("couldn't scan expired clicks row: %w", err)
+		}
+		stats = append(stats, st)
+	}
+
+	return stats, rows.Err()
+}
+
+// SetLinkRetention overrides the raw click retention of a link. nil restores
+// the global period and 0 keeps the link's raw clicks forever.
+func (s *Storage) SetLinkRetention(ctx context.Context, alias string, keep *time.Duration) error {
+	ctx, end := s.begin(ctx, "SetLinkRetention")
+	defer end()
+
+	var seconds *int64
+	if keep != nil {
+		v := int64(keep.Seconds())
+		seconds = &v
+	}
+
+	res, err := s.db.ExecContext(ctx, "UPDATE url SET raw_retention_seconds = $2 WHERE alias = $1 AND "+inDomain(3),
+		alias, seconds, storage.DomainFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("couldn't set retention: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("couldn't set retention: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// rolledUntil returns the time before which raw clicks are present in both
+// rollup tables, or the zero time if either has not been built yet.
+func (s *Storage) rolledUntil(ctx context.Context) (time.Time, error) {
+	hourly, err := s.watermark(ctx, hourlyRollup)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	daily, err := s.watermark(ctx, dailyRollup)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if daily.Before(hourly) {
+		return daily, nil
+	}
+
+	return hourly, nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneClicks_Visitors(t *testing.T) {
+	s := testStorage(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	expiredDay := now.AddDate(0, 0, -10).Truncate(24 * time.Hour)
+	recent := now.Add(-2 * time.Hour).Truncate(time.Hour)
+
+	keep := 72 * time.Hour
+	link, err := s.SaveURL(ctx, "https://example.com", "visitor-prune-test", storage.LinkOptions{RawRetention: &keep})
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = s.db.Exec("DELETE FROM url WHERE id = $1", link.ID) })
+
+	for _, name := range []string{hourlyRollup.name, dailyRollup.name} {
+		_, err := s.db.Exec(`INSERT INTO rollup_state (name, rolled_until) VALUES ($1, $2)
+			ON CONFLICT (name) DO UPDATE SET rolled_until = GREATEST(rollup_state.rolled_until, EXCLUDED.rolled_until)`,
+			name, recent)
+		require.NoError(t, err)
+	}
+
+	for _, v := range []struct {
+		bucket  time.Time
+		visitor string
+	}{
+		{expiredDay.Add(time.Hour), "a"},
+		{expiredDay.Add(5 * time.Hour), "a"},
+		{expiredDay.Add(5 * time.Hour), "b"},
+		{recent.Add(-time.Hour), "c"},
+	} {
+		_, err := s.db.Exec("INSERT INTO url_visitors_hourly (url_id, bucket, visitor_id) VALUES ($1, $2, $3)",
+			link.ID, v.bucket, v.visitor)
+		require.NoError(t, err)
+	}
+
+	_, err = s.PruneClicks(ctx, now, 0)
+	require.NoError(t, err)
+
+	var hourly []string
+	rows, err := s.db.Query("SELECT visitor_id FROM url_visitors_hourly WHERE url_id = $1 ORDER BY visitor_id", link.ID)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		hourly = append(hourly, id)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"c"}, hourly)
+
+	var bucket time.Time
+	var uniques int64
+	err = s.db.QueryRow("SELECT bucket, uniques FROM url_visitors_daily WHERE url_id = $1", link.ID).Scan(&bucket, &uniques)
+	require.NoError(t, err)
+	assert.True(t, expiredDay.Equal(bucket), bucket)
+	assert.Equal(t, int64(2), uniques)
+}
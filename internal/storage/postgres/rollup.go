@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/lib/timeseries"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// rollupGrace keeps the aggregator away from the newest raw rows, whose
+// inserting transactions may not have committed yet. Clicks saved late, with
+// created_at behind the watermark, don't depend on it: see rolledAt.
+const rollupGrace = time.Minute
+
+// rolledAt is the time by which a raw url_analytics row "a" is rolled up. Rows
+// are rolled up in the order they were inserted and added to the buckets of
+// their created_at, so a click saved after its bucket was aggregated is added
+// by the next run. Rows saved before inserted_at existed fall back to
+// created_at. Since created_at never exceeds inserted_at, every raw row at or
+// after the watermark is still unrolled.
+const rolledAt = "COALESCE(a.inserted_at, a.created_at)"
+
+type rollupTable struct {
+	name  string
+	unit  timeseries.Granularity
+	chunk time.Duration
+}
+
+var (
+	hourlyRollup = rollupTable{name: "url_clicks_hourly", unit: timeseries.Hour, chunk: 24 * time.Hour}
+	dailyRollup  = rollupTable{name: "url_clicks_daily", unit: timeseries.Day, chunk: 31 * 24 * time.Hour}
+)
+
+// dimensions unpivots a raw url_analytics row "a" into (dimension, value)
+// pairs. The "total" dimension carries overall clicks.
+const dimensions = `(VALUES
+	('total', ''),
+	('user_agent', a.user_agent),
+	('referrer', a.referrer),
+	('source', a.source),
+	('country', a.country),
+	('region', a.region),
+	('city', a.city)
+) AS d (dimension, value)`
+
+// RollupClicks aggregates raw clicks inserted before now into the hourly and
+// daily rollup tables. Only complete buckets are aggregated, and each run
+// continues from where the previous one stopped.
+func (s *Storage) RollupClicks(ctx context.Context, now time.Time) error {
+	ctx, end := s.begin(ctx, "RollupClicks")
+	defer end()
+
+	for _, t := range []rollupTable{hourlyRollup, dailyRollup} {
+		if err := s.rollup(ctx, t, now.Add(-rollupGrace)); err != nil {
+			return fmt.Errorf("couldn't roll up %s: %w", t.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) rollup(ctx context.Context, t rollupTable, now time.Time) error {
+	until := timeseries.Truncate(now, t.unit, time.UTC)
+
+	for {
+		done, err := s.rollupChunk(ctx, t, until)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// rollupChunk aggregates at most t.chunk of raw clicks in one transaction and
+// reports whether the watermark has reached until.
+func (s *Storage) rollupChunk(ctx context.Context, t rollupTable, until time.Time) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO rollup_state (name, rolled_until)
+		SELECT $1, COALESCE(date_trunc($2, MIN(created_at), 'UTC'), $3) FROM url_analytics
+		ON CONFLICT (name) DO NOTHING`, t.name, string(t.unit), until)
+	if err != nil {
+		return false, fmt.Errorf("couldn't init watermark: %w", err)
+	}
+
+	var from time.Time
+	err = tx.QueryRowContext(ctx, "SELECT rolled_until FROM rollup_state WHERE name = $1 FOR UPDATE", t.name).Scan(&from)
+	if err != nil {
+		return false, fmt.Errorf("couldn't get watermark: %w", err)
+	}
+
+	if !from.Before(until) {
+		return true, tx.Commit()
+	}
+
+	to := from.Add(t.chunk)
+	if to.After(until) {
+		to = until
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %[1]s AS r (url_id, bucket, dimension, value, clicks)
+		SELECT a.url_id, date_trunc('%[2]s', a.created_at, 'UTC'), d.dimension, d.value, COUNT(*)
+		FROM url_analytics a CROSS JOIN LATERAL %[3]s
+		WHERE %[4]s >= $1 AND %[4]s < $2
+		GROUP BY 1, 2, 3, 4
+		ON CONFLICT (url_id, dimension, bucket, value)
+		DO UPDATE SET clicks = r.clicks + EXCLUDED.clicks`,
+		t.name, t.unit, dimensions, rolledAt)
+
+	if _, err := tx.ExecContext(ctx, query, from, to); err != nil {
+		return false, fmt.Errorf("couldn't aggregate clicks: %w", err)
+	}
+
+	if t == hourlyRollup {
+		_, err = tx.ExecContext(ctx, `INSERT INTO url_visitors_hourly (url_id, bucket, visitor_id)
+			SELECT DISTINCT a.url_id, date_trunc('hour', a.created_at, 'UTC'), a.visitor_id FROM url_analytics a
+			WHERE `+rolledAt+` >= $1 AND `+rolledAt+` < $2 AND a.visitor_id <> ''
+			ON CONFLICT DO NOTHING`, from, to)
+		if err != nil {
+			return false, fmt.Errorf("couldn't aggregate visitors: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE rollup_state SET rolled_until = $2 WHERE name = $1", t.name, to); err != nil {
+		return false, fmt.Errorf("couldn't update watermark: %w", err)
+	}
+
+	return !to.Before(until), tx.Commit()
+}
+
+// watermark returns the exclusive upper bound of clicks aggregated into t,
+// or the zero time if the aggregator has not run yet.
+func (s *Storage) watermark(ctx context.Context, t rollupTable) (time.Time, error) {
+	var until time.Time
+	err := s.db.QueryRowContext(ctx, "SELECT rolled_until FROM rollup_state WHERE name = $1", t.name).Scan(&until)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("couldn't get %s watermark: %w", t.name, err)
+	}
+
+	return until, nil
+}
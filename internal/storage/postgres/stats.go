@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"analiticsURLShortener/internal/storage"
+	"context"
+	"fmt"
+)
+
+// totals is a CTE with per-bucket clicks of every url of the domain, built
+// like clickSource.units but for the "total" dimension only.
+//
+// Parameters: $1-$2 rollup range, $3-$4 query range, $5 granularity,
+// $6 time zone, $7 domain.
+func (c clickSource) totals() string {
+	return fmt.Sprintf(`WITH links AS (
+		SELECT id FROM url WHERE %s
+	), totals AS (
+		SELECT url_id, bucket, clicks FROM %s
+		WHERE dimension = 'total' AND bucket >= $1 AND bucket < $2 AND url_id IN (SELECT id FROM links)
+		UNION ALL
+		SELECT url_id, date_trunc($5, created_at, $6), COUNT(*) FROM url_analytics
+		WHERE ((created_at >= $3 AND created_at < $1) OR (created_at >= $2 AND created_at < $4))
+			AND url_id IN (SELECT id FROM links)
+		GROUP BY 1, 2
+	) `, inDomain(7), c.table.name)
+}
+
+func (c clickSource) totalsArgs(ctx context.Context, q storage.AnalyticsQuery, extra ...any) []any {
+	args := []any{c.from, c.to, q.From, q.To, string(q.Granularity), q.Location.String(), storage.DomainFromContext(ctx)}
+
+	return append(args, extra...)
+}
+
+// GetGlobalStats returns the number of links, clicks across all links in the
+// range of q and the limit most clicked links, all within the domain of ctx.
+func (s *Storage) GetGlobalStats(ctx context.Context, q storage.AnalyticsQuery, limit int) (storage.GlobalStats, error) {
+	ctx, end := s.begin(ctx, "GetGlobalStats")
+	defer end()
+
+	var stats storage.GlobalStats
+
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM url WHERE "+inDomain(1), storage.DomainFromContext(ctx)).
+		Scan(&stats.TotalLinks)
+	if err != nil {
+		return storage.GlobalStats{}, fmt.Errorf("couldn't count links: %w", err)
+	}
+
+	src, err := s.clickSource(ctx, q)
+	if err != nil {
+		return storage.GlobalStats{}, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, src.totals()+`SELECT date_trunc($5, bucket, $6) AS b, SUM(clicks)
+		FROM totals GROUP BY b`, src.totalsArgs(ctx, q)...)
+	if err != nil {
+		return storage.GlobalStats{}, fmt.Errorf("couldn't get click series: %w", err)
+	}
+	defer rows.Close()
+
+	byBucket := make(map[int64]storage.Point)
+	for rows.Next() {
+		var p storage.Point
+		if err := rows.Scan(&p.Time, &p.Clicks); err != nil {
+			return storage.GlobalStats{}, fmt.Errorf("couldn't scan series row: %w", err)
+		}
+		byBucket[p.Time.Unix()] = p
+		stats.TotalClicks += p.Clicks
+	}
+	if err := rows.Err(); err != nil {
+		return storage.GlobalStats{}, fmt.Errorf("couldn't get click series: %w", err)
+	}
+	stats.Series = fillSeries(byBucket, q)
+
+	stats.TopLinks, err = s.topLinks(ctx, src, q, limit)
+	if err != nil {
+		return storage.GlobalStats{}, fmt.Errorf("couldn't get top links: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (s *Storage) topLinks(ctx context.Context, src clickSource, q storage.AnalyticsQuery, limit int) ([]storage.LinkClicks, error) {
+	rows, err := s.db.QueryContext(ctx, src.totals()+`SELECT COALESCE(d.host, ''), u.alias, u.url, t.clicks FROM (
+			SELECT url_id, SUM(clicks) AS clicks FROM totals GROUP BY url_id
+		) t JOIN url u ON u.id = t.url_id LEFT JOIN domain d ON d.id = u.domain_id
+		ORDER BY t.clicks DESC, u.alias LIMIT $8`, src.totalsArgs(ctx, q, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make([]storage.LinkClicks, 0, limit)
+	for rows.Next() {
+		var l storage.LinkClicks
+		if err := rows.Scan(&l.Domain, &l.Alias, &l.URL, &l.Clicks); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+
+	return links, rows.Err()
+}
@@ -1,15 +1,173 @@
 package storage
 
-import "errors"
+import (
+	"analiticsURLShortener/internal/lib/timeseries"
+	"context"
+	"errors"
+	"time"
+)
 
 var (
-	ErrURLNotFound = errors.New("URL not found")
-	ErrURLExists   = errors.New("URL already exists")
+	ErrURLNotFound    = errors.New("URL not found")
+	ErrURLExists      = errors.New("URL already exists")
+	ErrDomainNotFound = errors.New("domain not found")
+	ErrDomainExists   = errors.New("domain already exists")
+	ErrAPIKeyNotFound = errors.New("API key not found")
 )
 
+type domainKey struct{}
+
+// WithDomain scopes aliases used with ctx to the links of a registered
+// domain. Without it, or with an empty host, aliases belong to the default
+// domain of the deployment.
+func WithDomain(ctx context.Context, host string) context.Context {
+	return context.WithValue(ctx, domainKey{}, host)
+}
+
+// DomainFromContext returns the domain set with WithDomain, or "" for the
+// default domain.
+func DomainFromContext(ctx context.Context) string {
+	host, _ := ctx.Value(domainKey{}).(string)
+	return host
+}
+
+// Domain is a custom short link domain with its own alias namespace.
+type Domain struct {
+	ID        int64
+	Host      string
+	CreatedAt time.Time
+}
+
+// APIKey lets a client create links on one domain.
+type APIKey struct {
+	Prefix string
+	// SecretHash is the SHA-256 hash of the secret part of the key.
+	SecretHash []byte
+	// Domain is the host of the key's domain, "" for the default domain.
+	Domain    string
+	CreatedAt time.Time
+}
+
+// Click holds the request attributes recorded for a single redirect.
+type Click struct {
+	UserAgent string
+	Referrer  string
+	Source    string
+	IP        string
+	Country   string
+	Region    string
+	City      string
+	// VisitorID is a salted hash of the client IP and user agent; the salt
+	// changes every UTC day, so the same person gets a new ID each day.
+	VisitorID string
+}
+
+// Link is a stored short link.
+type Link struct {
+	ID    int64
+	Alias string
+	// Domain is the host of the link's domain, "" for the default domain.
+	Domain    string
+	URL       string
+	CreatedAt time.Time
+	Options   LinkOptions
+}
+
+// LinkOptions are the per-link settings.
+type LinkOptions struct {
+	// RawRetention overrides the global raw click retention; nil uses the
+	// global period and 0 keeps raw clicks forever.
+	RawRetention *time.Duration
+	// Embeddable allows the click count and trend of the link to be shown
+	// without an API key: on badges, sparklines and the preview page. The
+	// analytics endpoints don't depend on it.
+	Embeddable bool
+}
+
+// ClickEvent is a stored click of a link.
+type ClickEvent struct {
+	// Domain is the host of the link's domain, "" for the default domain.
+	Domain string
+	Alias  string
+	Time   time.Time
+	Click
+}
+
+// Count is a single entry of a top-N breakdown.
+type Count struct {
+	Value  string
+	Clicks int64
+}
+
+// PruneStat describes the expired raw clicks of a single link.
+type PruneStat struct {
+	Alias  string
+	Clicks int64
+	Oldest time.Time
+	Newest time.Time
+}
+
+// PartitionReport lists the click partitions created and removed by a
+// maintenance run.
+type PartitionReport struct {
+	Created []string
+	Removed []string
+}
+
+// AnalyticsQuery selects clicks in [From, To) and buckets them by
+// Granularity in Location.
+type AnalyticsQuery struct {
+	From        time.Time
+	To          time.Time
+	Granularity timeseries.Granularity
+	Location    *time.Location
+}
+
+// Point is a single bucket of a click time series.
+type Point struct {
+	Time    time.Time
+	Clicks  int64
+	Uniques int64
+}
+
 type AnalyticsData struct {
 	TotalClicks int64
-	UserAgents  map[string]int64
-	Daily       map[string]int64
-	Monthly     map[string]int64
+	// UniqueVisitors counts distinct visitor IDs. Because the salt rotates
+	// daily, it is the sum of daily uniques rather than all-time people.
+	UniqueVisitors int64
+	// Series is ordered by time and has an entry for every bucket of the query.
+	Series     []Point
+	UserAgents map[string]int64
+	Referrers  []Count
+	Sources    []Count
+	Countries  []Count
+	Regions    []Count
+	Cities     []Count
+}
+
+// LinkClicks is a single entry of the top links leaderboard.
+type LinkClicks struct {
+	// Domain is the host of the link's domain, "" for the default domain.
+	Domain string
+	Alias  string
+	URL    string
+	Clicks int64
 }
+
+// GlobalStats summarizes clicks across all links.
+type GlobalStats struct {
+	TotalLinks  int64
+	TotalClicks int64
+	// Series counts clicks only; uniques are per link and can't be summed.
+	Series   []Point
+	TopLinks []LinkClicks
+}
+
+// LinkAnalytics is the analytics of one link in a comparison.
+type LinkAnalytics struct {
+	Alias string
+	AnalyticsData
+}
+
+// Heatmap counts clicks by day of week, starting with Monday, and hour of day.
+type Heatmap [7][24]int64
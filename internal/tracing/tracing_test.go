@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"analiticsURLShortener/internal/config"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+
+	shutdown, err := Setup(context.Background(), config.Tracing{Exporter: ExporterFile, File: path, SampleRatio: 1}, "test")
+	require.NoError(t, err)
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "operation")
+	traceID := TraceID(ctx)
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	assert.Len(t, traceID, 32)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"operation"`)
+	assert.Contains(t, string(data), traceID)
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), config.Tracing{Exporter: "zipkin"}, "test")
+	assert.Error(t, err)
+}
+
+func TestTraceID_Empty(t *testing.T) {
+	assert.Empty(t, TraceID(context.Background()))
+}
@@ -27,7 +27,7 @@ async function shortenUrl() {
         const data = await response.json();
 
         if (response.ok) {
-            const shortUrl = `${window.location.origin}/s/${data.alias}`;
+            const shortUrl = data.short_url;
             resultDiv.innerHTML = `<p>Сокращенная ссылка: <a href="${shortUrl}" target="_blank">${shortUrl}</a></p>`;
         } else {
             resultDiv.innerHTML = `<p style="color: red;">Ошибка: ${data.error}</p>`;
@@ -0,0 +1,11 @@
+// Package static embeds the web UI. Files with a name.gz sibling are served
+// precompressed to clients that accept gzip; run go generate after editing
+// them. Stale variants are detected and skipped.
+package static
+
+import "embed"
+
+//go:generate sh -c "gzip -9 -k -f -n *.html *.js"
+
+//go:embed *.html *.js *.gz
+var FS embed.FS
@@ -0,0 +1,34 @@
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPrecompressed fails when an asset was edited without running go generate.
+func TestPrecompressed(t *testing.T) {
+	names, err := fs.Glob(FS, "*.gz")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+
+	for _, name := range names {
+		gz, err := FS.ReadFile(name)
+		require.NoError(t, err)
+		data, err := FS.ReadFile(strings.TrimSuffix(name, ".gz"))
+		require.NoError(t, err, name)
+
+		zr, err := gzip.NewReader(bytes.NewReader(gz))
+		require.NoError(t, err, name)
+		plain, err := io.ReadAll(zr)
+		require.NoError(t, err, name)
+
+		assert.Equal(t, string(data), string(plain), "%s is stale, run go generate", name)
+	}
+}